@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Bitmasks precomputed for boards small enough to fit in a uint64, see BitBoard.
+type geometryMasks struct {
+	neighbors []uint64
+	// The 7 cells of the hexagon centered on each cell, 0 if the cell isn't a hexagon center.
+	hexagons []uint64
+}
+
+func (g *Geometry) buildMasks() *geometryMasks {
+	if g.NumCells() > 64 {
+		return nil
+	}
+	masks := &geometryMasks{neighbors: make([]uint64, g.NumCells()), hexagons: make([]uint64, g.NumCells())}
+	index := 0
+	for lineNum, line := range g.lines {
+		for col := range line {
+			coords := Coords{Line: lineNum, Col: col}
+			for _, neighbor := range g.Neighbors(coords) {
+				masks.neighbors[index] |= 1 << uint(g.Index(neighbor))
+			}
+			if g.IsHexagonCenter(coords) {
+				masks.hexagons[index] = masks.neighbors[index] | 1<<uint(index)
+			}
+			index++
+		}
+	}
+	return masks
+}
+
+// BitBoard is a compact representation of a Board for boards of up to 64
+// cells, with one bit per cell for each color and flag. Cells are indexed in
+// line order (see Geometry.Index).
+//
+// An initialized Board keeps a BitBoard up to date as it changes, so that
+// HexiconRules detects hexagons and super hexagons with mask operations
+// instead of walking the nodes. Moves don't change the side to move of a
+// Board, so the one it keeps has the side the board had when it was
+// initialized.
+type BitBoard struct {
+	Red      uint64
+	VeryRed  uint64
+	Blue     uint64
+	VeryBlue uint64
+	Cleared  uint64
+	Used     uint64
+	Swapped  uint64
+	Letters  []byte
+	Score    BoardScore
+	ToMove   Mover
+	rules    Rules
+	geometry *Geometry
+}
+
+// NewBitBoard converts an initialized board.
+func NewBitBoard(b *Board) (*BitBoard, error) {
+	if b.geometry.masks == nil {
+		return nil, fmt.Errorf("a board with %d cells does not fit in a bitboard", b.geometry.NumCells())
+	}
+	bb := &BitBoard{
+		Letters:  make([]byte, b.geometry.NumCells()),
+		Score:    b.Score,
+		ToMove:   b.ToMove,
+		rules:    b.rules,
+		geometry: b.geometry,
+	}
+	for idx, node := range b.nodesFlat() {
+		bb.set(idx, node)
+	}
+	return bb, nil
+}
+
+// Sets the bits of the cell to the state of the node.
+func (bb *BitBoard) set(idx int, node *BoardNode) {
+	bit := uint64(1) << uint(idx)
+	bb.clear(bit)
+	switch node.Color {
+	case Red:
+		bb.Red |= bit
+	case VeryRed:
+		bb.VeryRed |= bit
+	case Blue:
+		bb.Blue |= bit
+	case VeryBlue:
+		bb.VeryBlue |= bit
+	}
+	bb.Cleared = setBit(bb.Cleared, bit, node.cleared)
+	bb.Used = setBit(bb.Used, bit, node.used)
+	bb.Swapped = setBit(bb.Swapped, bit, node.IsSwapped)
+	bb.Letters[idx] = node.Letter
+}
+
+func setBit(mask uint64, bit uint64, value bool) uint64 {
+	if value {
+		return mask | bit
+	}
+	return mask &^ bit
+}
+
+func (bb *BitBoard) clone() *BitBoard {
+	bitBoard := *bb
+	bitBoard.Letters = append([]byte{}, bb.Letters...)
+	return &bitBoard
+}
+
+// Board converts back to an initialized Board.
+func (bb *BitBoard) Board() *Board {
+	g := bb.geometry
+	board := &Board{
+		Score:  bb.Score,
+		ToMove: bb.ToMove,
+		Nodes:  make([][]*BoardNode, g.NumLines()),
+		rules:  bb.rules,
+	}
+	idx := 0
+	for lineNum := 0; lineNum < g.NumLines(); lineNum++ {
+		board.Nodes[lineNum] = make([]*BoardNode, g.LineLength(lineNum))
+		for col := range board.Nodes[lineNum] {
+			board.Nodes[lineNum][col] = &BoardNode{
+				Letter: bb.Letters[idx],
+				Char:   string(bb.Letters[idx]),
+				Color:  bb.colorAt(idx),
+			}
+			idx++
+		}
+	}
+	board.Initialize()
+
+	// Initialize resets the flags, so they have to be copied afterwards.
+	for idx, node := range board.nodesFlat() {
+		bit := uint64(1) << uint(idx)
+		node.cleared = bb.Cleared&bit != 0
+		node.used = bb.Used&bit != 0
+		node.IsSwapped = bb.Swapped&bit != 0
+	}
+	board.hash = board.computeHash()
+	board.bits = bb.clone()
+	return board
+}
+
+func (bb *BitBoard) colorAt(idx int) Color {
+	bit := uint64(1) << uint(idx)
+	switch {
+	case bb.Red&bit != 0:
+		return Red
+	case bb.VeryRed&bit != 0:
+		return VeryRed
+	case bb.Blue&bit != 0:
+		return Blue
+	case bb.VeryBlue&bit != 0:
+		return VeryBlue
+	}
+	return None
+}
+
+func (bb *BitBoard) colored() uint64 {
+	return bb.Red | bb.VeryRed | bb.Blue | bb.VeryBlue
+}
+
+// Removes the color from the cells of the mask.
+func (bb *BitBoard) clear(mask uint64) {
+	bb.Red &^= mask
+	bb.VeryRed &^= mask
+	bb.Blue &^= mask
+	bb.VeryBlue &^= mask
+}
+
+// HexagonOwner returns the side that captures the hexagon centered on the
+// cell, following the default Hexicon rules: once all of its cells are
+// colored and its center isn't captured, it goes to the side with the most
+// cells. It returns "" if the hexagon isn't captured.
+func (bb *BitBoard) HexagonOwner(center int) Mover {
+	cells := bb.geometry.masks.hexagons[center]
+	if cells == 0 || (bb.Red|bb.Blue)&(1<<uint(center)) == 0 || cells&^bb.colored() != 0 {
+		return ""
+	}
+	if bits.OnesCount64(cells&(bb.Red|bb.VeryRed)) > bits.OnesCount64(cells&(bb.Blue|bb.VeryBlue)) {
+		return RedMover
+	}
+	return BlueMover
+}
+
+// IsSuperHexagon reports whether all the cells of the hexagon centered on the cell are captured.
+func (bb *BitBoard) IsSuperHexagon(center int) bool {
+	cells := bb.geometry.masks.hexagons[center]
+	return cells != 0 && cells&^(bb.VeryRed|bb.VeryBlue) == 0
+}
+
+// Updates the bitboard of the board after the node changed.
+func (b *Board) updateBits(node *BoardNode) {
+	if b.bits != nil {
+		b.bits.set(node.index, node)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Plays random games and checks, after every move, that the bitboard the
+// board keeps matches one converted from scratch, and that playing the move
+// with mask-based hexagon detection gives the same board as walking the nodes.
+func TestBitBoardMatchesPlay(t *testing.T) {
+	trie := loadTrie()
+	for seed := int64(1); seed <= 3; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		board := RandomBoard(rng)
+		mover := Mover(BlueMover)
+		captures := 0
+		for moveNum := 0; moveNum < 60 && board.GetTerminalResult(BlueMover) == -1; moveNum++ {
+			words := FindWords(board, trie, mover)
+			if len(words) == 0 {
+				break
+			}
+			word := words[rng.Intn(len(words))]
+
+			walked := board.clone()
+			walked.bits = nil
+			if _, err := walked.MakeMove(word, mover); err != nil {
+				t.Fatal(err)
+			}
+			before := board.clone()
+			delta, err := board.MakeMove(word, mover)
+			if err != nil {
+				t.Fatal(err)
+			}
+			captures += len(delta.Hexagons)
+			if !reflect.DeepEqual(board.Colors(), walked.Colors()) || board.Score != walked.Score {
+				t.Fatalf("seed %d move %d: %s differs from walking the nodes", seed, moveNum, word)
+			}
+			checkBits(t, board)
+
+			// Undoing the move has to restore the masks too.
+			if moveNum%5 == 0 {
+				board.UnmakeMove(delta)
+				checkBits(t, board)
+				if !reflect.DeepEqual(board.bits, before.bits) {
+					t.Fatalf("seed %d move %d: undoing %s didn't restore the bitboard", seed, moveNum, word)
+				}
+				if _, err := board.MakeMove(word, mover); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, node := range board.nodesFlat() {
+				if node.cleared {
+					if err := board.Refill(node.coords, RefillDistribution.Sample(rng)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			checkBits(t, board)
+			mover = mover.Opposite()
+		}
+		if captures == 0 {
+			t.Errorf("seed %d: no hexagons were captured", seed)
+		}
+	}
+}
+
+func checkBits(t *testing.T, board *Board) {
+	t.Helper()
+	bits, err := NewBitBoard(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(board.bits, bits) {
+		t.Fatalf("bitboard is out of date:\n%+v\nexpected\n%+v", board.bits, bits)
+	}
+	for idx, node := range board.nodesFlat() {
+		if owner, expected := bits.HexagonOwner(idx), node.checkHexagon(board); owner != expected {
+			t.Fatalf("hexagon %v: owner %q, expected %q", node.coords, owner, expected)
+		}
+		if super, expected := bits.IsSuperHexagon(idx), node.isSuperHexagon(board); super != expected {
+			t.Fatalf("hexagon %v: super hexagon %t, expected %t", node.coords, super, expected)
+		}
+	}
+}
+
+func TestBitBoardRoundTrip(t *testing.T) {
+	board := RandomBoard(rand.New(rand.NewSource(1)))
+	board.Nodes[4][1].Color = Red
+	board.Nodes[6][2].Color = VeryBlue
+	board.Score = BoardScore{Red: 3, Blue: 5}
+	board.ToMove = RedMover
+	rules, err := GetRules("short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	board.SetRules(rules)
+	board.Initialize()
+
+	bits, err := NewBitBoard(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted := bits.Board()
+	if FormatBoard(converted) != FormatBoard(board) || converted.Score != board.Score || converted.hash != board.hash {
+		t.Fatalf("round trip changed the board:\n%s\n%s", FormatBoard(board), FormatBoard(converted))
+	}
+	if converted.ToMove != RedMover {
+		t.Errorf("round trip changed the side to move to %q", converted.ToMove)
+	}
+	if converted.Rules() != rules {
+		t.Error("round trip changed the rules")
+	}
+}
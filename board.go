@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/fatih/color"
@@ -26,28 +25,62 @@ type BoardScore struct {
 }
 
 type BoardNode struct {
-	Letter    byte
+	Letter    byte   `json:"-"`
 	Char      string `json:"char"`
 	Color     Color  `json:"color"`
 	cleared   bool
 	used      bool
 	coords    Coords
-	IsSwapped bool
+	index     int
+	IsSwapped bool `json:"-"`
 	neighbors []*BoardNode
 }
 
 type Board struct {
-	Score        BoardScore     `json:"score"`
-	Nodes        [][]*BoardNode `json:"nodes"`
+	Score BoardScore     `json:"score"`
+	Nodes [][]*BoardNode `json:"nodes"`
+	// Side to move in the position, see SideToMove.
+	ToMove       Mover `json:"toMove,omitempty"`
 	neighbors    [][][]*BoardNode
 	nodesList    []*BoardNode
-	HasSwapped   bool
-	SwappedNodes []*BoardNode
+	HasSwapped   bool         `json:"-"`
+	SwappedNodes []*BoardNode `json:"-"`
+	geometry     *Geometry
+	journal      *MoveDelta
+	hash         uint64
+	rules        Rules
+	// Masks of the board, kept up to date as it changes. nil if the board doesn't fit in a BitBoard.
+	bits *BitBoard
 }
 
 type Coords struct {
-	Line int
-	Col  int
+	Line int `json:"line"`
+	Col  int `json:"col"`
+}
+
+// Rule violations returned by Play and SwapNodes.
+var (
+	ErrWrongColor     = errors.New("tile belongs to the other player")
+	ErrTileCaptured   = errors.New("tile is captured")
+	ErrTileUsed       = errors.New("tile has already been used")
+	ErrAlreadySwapped = errors.New("a swap has already been made")
+	ErrNotSwapped     = errors.New("no swap has been made")
+	ErrNotCleared     = errors.New("tile has not been cleared")
+)
+
+// TileError is a rule violation involving a specific tile. Use errors.Is to
+// check for the underlying rule.
+type TileError struct {
+	Coords Coords
+	Err    error
+}
+
+func (e *TileError) Error() string {
+	return fmt.Sprintf("tile %d,%d: %s", e.Coords.Line, e.Coords.Col, e.Err)
+}
+
+func (e *TileError) Unwrap() error {
+	return e.Err
 }
 
 func (bn *BoardNode) String() string {
@@ -55,48 +88,70 @@ func (bn *BoardNode) String() string {
 }
 
 func (b *Board) Initialize() {
+	b.geometry = GetGeometry(radiusForLines(len(b.Nodes)))
+
 	// Set the node coordinates
 	for lineNum := 0; lineNum < len(b.Nodes); lineNum++ {
 		for nodeNum := 0; nodeNum < len(b.Nodes[lineNum]); nodeNum++ {
 			b.Nodes[lineNum][nodeNum].coords = Coords{Line: lineNum, Col: nodeNum}
 			b.Nodes[lineNum][nodeNum].used = false
 			b.Nodes[lineNum][nodeNum].cleared = false
+			b.Nodes[lineNum][nodeNum].index = b.geometry.Index(Coords{Line: lineNum, Col: nodeNum})
 		}
 	}
+	b.nodesList = nil
+	b.linkNeighbors()
+	b.hash = b.computeHash()
+	b.bits, _ = NewBitBoard(b)
 }
 
-// This mutates the board
-func (b *Board) Play(word *Word, mover Mover) {
+// This mutates the board. The board is left untouched if the move is invalid.
+func (b *Board) Play(word *Word, mover Mover) error {
+	for _, letter := range word.letters {
+		node := b.Nodes[letter.coords.Line][letter.coords.Col]
+		if !mover.IsMatching(node.Color) {
+			return &TileError{Coords: letter.coords, Err: ErrWrongColor}
+		}
+	}
 	for _, letter := range word.letters {
 		node := b.Nodes[letter.coords.Line][letter.coords.Col]
+		if node.cleared {
+			// The word was found by guessing the letter that refills the tile.
+			b.touch(node)
+			b.setLetter(node, letter.Letter)
+			b.setCleared(node, false)
+		}
 		if node.Color == None {
-			node.Color = mover.GetColor()
-		} else if !mover.IsMatching(node.Color) {
-			fmt.Println(b.String())
-			fmt.Println(word.SwappedNodes)
-			log.Fatalln("Invalid move: As", mover, "letter:", letter.coords, string(letter.Letter), "Node:", node.Color, node.coords, "swapped", node.IsSwapped, "letter", string(node.Letter), "\n", b.StringWithWord(word))
+			b.touch(node)
+			b.setColor(node, mover.GetColor())
 		}
 	}
 
+	rules := b.Rules()
 	hexagonCenters := []*BoardNode{}
 	for lineNum := 0; lineNum < len(b.Nodes); lineNum++ {
 		for nodeNum := 0; nodeNum < len(b.Nodes[lineNum]); nodeNum++ {
 			node := b.Nodes[lineNum][nodeNum]
-			hexagon := node.checkHexagon(b)
+			hexagon := rules.HexagonOwner(b, node)
 			if hexagon != "" {
 				// fmt.Println("Hexagon", node.coords, "is", hexagon)
 				hexagonCenters = append(hexagonCenters, node)
+				if b.journal != nil {
+					b.journal.Hexagons = append(b.journal.Hexagons, node.coords)
+				}
+				score := b.Score
 				if hexagon == Red {
-					b.Score.Red++
+					score.Red++
 				} else {
-					b.Score.Blue++
+					score.Blue++
 				}
+				b.setScore(score)
 			}
 		}
 	}
 
 	for _, node := range hexagonCenters {
-		node.clearHexagon(b, mover)
+		rules.CaptureHexagon(b, node, mover)
 	}
 
 	if len(hexagonCenters) > 0 {
@@ -104,38 +159,64 @@ func (b *Board) Play(word *Word, mover Mover) {
 		for lineNum := 0; lineNum < len(b.Nodes); lineNum++ {
 			for nodeNum := 0; nodeNum < len(b.Nodes[lineNum]); nodeNum++ {
 				node := b.Nodes[lineNum][nodeNum]
-				if node.isSuperHexagon(b) {
+				if rules.IsSuperHexagon(b, node) {
 					superHexagons = append(superHexagons, node)
 				}
 			}
 		}
 
 		for _, node := range superHexagons {
-			node.clearSuperHexagon(b)
+			rules.ClearSuperHexagon(b, node)
 		}
 	}
 
 	b.ResetSwap()
+	return nil
 }
 
+// The neighbors are linked when the board is initialized, so looking them up
+// doesn't modify the board.
 func (b *Board) GetNeighbors(node *BoardNode) []*BoardNode {
 	if node.neighbors != nil {
 		return node.neighbors
 	}
-	neighbors := make([]*BoardNode, 0, 6)
-	neighbor_coords := coords_to_neighbors[node.coords.Line][node.coords.Col]
+	return b.findNeighbors(node)
+}
+
+func (b *Board) findNeighbors(node *BoardNode) []*BoardNode {
+	neighbor_coords := b.geometry.Neighbors(node.coords)
+	neighbors := make([]*BoardNode, 0, len(neighbor_coords))
 
 	for _, coord := range neighbor_coords {
-		neighbors = append(neighbors, b.Nodes[coord[0]][coord[1]])
+		neighbors = append(neighbors, b.Nodes[coord.Line][coord.Col])
 	}
-	node.neighbors = neighbors
 	return neighbors
 }
 
-func (b *Board) GetTerminalResult() float64 {
-	if b.Score.Blue >= 16 {
+func (b *Board) linkNeighbors() {
+	for _, node := range b.nodesFlat() {
+		node.neighbors = b.findNeighbors(node)
+	}
+}
+
+// SideToMove is the side the board was given to move, blue if it wasn't given.
+func (b *Board) SideToMove() Mover {
+	if b.ToMove == "" {
+		return BlueMover
+	}
+	return b.ToMove
+}
+
+// Returns 1 if side has won, 0 if it has lost and -1 if the game isn't over.
+func (b *Board) GetTerminalResult(side Mover) float64 {
+	winningScore := b.Rules().WinningScore()
+	ownScore, opponentScore := b.Score.Blue, b.Score.Red
+	if side == RedMover {
+		ownScore, opponentScore = opponentScore, ownScore
+	}
+	if ownScore >= winningScore {
 		return 1
-	} else if b.Score.Red >= 16 {
+	} else if opponentScore >= winningScore {
 		return 0
 	} else {
 		return -1
@@ -144,41 +225,6 @@ func (b *Board) GetTerminalResult() float64 {
 
 const NUM_SQUARES = 61
 
-// between 0 and 1
-func (b *Board) heuristic() float64 {
-	var closenessToWinning float64
-	var closenessToLosing float64
-	closenessToWinning = float64(b.Score.Blue) / 16.0
-	closenessToLosing = 1 - (float64(b.Score.Red) / 16.0)
-	numBlueSquareNeighbors := 0
-	numRedSquareNeighbors := 0
-	numNeighbors := 0
-	for lineNum := 0; lineNum < len(b.Nodes); lineNum++ {
-		for nodeNum := 0; nodeNum < len(b.Nodes[lineNum]); nodeNum++ {
-			neighbors := b.GetNeighbors(b.Nodes[lineNum][nodeNum])
-			for _, neighbor := range neighbors {
-				numNeighbors++
-				if neighbor.Color == Red || neighbor.Color == VeryRed {
-					numRedSquareNeighbors++
-				} else if neighbor.Color == Blue || neighbor.Color == VeryBlue {
-					numBlueSquareNeighbors++
-				}
-			}
-		}
-	}
-
-	blueSquareNeighborRatio := float64(numBlueSquareNeighbors) / float64(numNeighbors)
-	redSquareNeighborRatio := 1 - (float64(numRedSquareNeighbors) / float64(numNeighbors))
-
-	result := closenessToWinning*.6 + closenessToLosing*.15 + blueSquareNeighborRatio*.2 + redSquareNeighborRatio*.05
-
-	// fmt.Println(b.String())
-	// fmt.Println("Blue", blueSquareRatio, "Red", redSquareRatio)
-	// fmt.Println("Result", result, "Closeness to winning:", closenessToWinning, "Closeness to losing:", closenessToLosing, "Blue squares:", numBlueSquares, "Red squares:", numRedSquares)
-
-	return result
-}
-
 func (n *BoardNode) checkHexagon(board *Board) Mover {
 	if n.Color == None || n.Color == VeryRed || n.Color == VeryBlue {
 		return ""
@@ -220,7 +266,11 @@ func (b *Board) clone() *Board {
 			Blue: b.Score.Blue,
 		},
 		// HasSwapped: b.HasSwapped,
-		Nodes: make([][]*BoardNode, len(b.Nodes)),
+		Nodes:    make([][]*BoardNode, len(b.Nodes)),
+		ToMove:   b.ToMove,
+		geometry: b.geometry,
+		hash:     b.hash,
+		rules:    b.rules,
 	}
 	for lineNum := 0; lineNum < len(b.Nodes); lineNum++ {
 		board.Nodes[lineNum] = make([]*BoardNode, len(b.Nodes[lineNum]))
@@ -228,13 +278,19 @@ func (b *Board) clone() *Board {
 			node := b.Nodes[lineNum][nodeNum]
 			board.Nodes[lineNum][nodeNum] = &BoardNode{
 				Letter:    node.Letter,
+				Char:      node.Char,
 				Color:     node.Color,
 				cleared:   node.cleared,
 				coords:    node.coords,
+				index:     node.index,
 				IsSwapped: node.IsSwapped,
 			}
 		}
 	}
+	board.linkNeighbors()
+	if b.bits != nil {
+		board.bits = b.bits.clone()
+	}
 	// if board.HasSwapped {
 	// 	board.SwappedNodes = make([]*BoardNode, 0, 2)
 	// 	board.SwappedNodes = append(board.SwappedNodes, board.Nodes[b.SwappedNodes[0].coords.Line][b.SwappedNodes[0].coords.Col], board.Nodes[b.SwappedNodes[1].coords.Line][b.SwappedNodes[1].coords.Col])
@@ -244,17 +300,19 @@ func (b *Board) clone() *Board {
 }
 
 func (n *BoardNode) clearHexagon(board *Board, mover Mover) {
+	board.touch(n)
 	if mover == RedMover {
-		n.Color = VeryRed
+		board.setColor(n, VeryRed)
 	} else if mover == BlueMover {
-		n.Color = VeryBlue
+		board.setColor(n, VeryBlue)
 	}
 
 	neighbors := board.GetNeighbors(n)
 	for _, neighbor := range neighbors {
 		if neighbor.Color == Red || neighbor.Color == Blue {
-			neighbor.Color = None
-			neighbor.cleared = true
+			board.touch(neighbor)
+			board.setColor(neighbor, None)
+			board.setCleared(neighbor, true)
 		}
 	}
 }
@@ -277,48 +335,64 @@ func (n *BoardNode) isSuperHexagon(board *Board) bool {
 }
 
 func (n *BoardNode) clearSuperHexagon(board *Board) {
-	n.Color = None
-	n.cleared = true
+	board.touch(n)
+	board.setColor(n, None)
+	board.setCleared(n, true)
 
 	neighbors := board.GetNeighbors(n)
 	for _, neighbor := range neighbors {
-		neighbor.Color = None
-		neighbor.cleared = true
+		board.touch(neighbor)
+		board.setColor(neighbor, None)
+		board.setCleared(neighbor, true)
 	}
 }
 
+// Refill puts the letter the game dealt into a cleared tile.
+func (b *Board) Refill(coords Coords, letter byte) error {
+	node := b.Nodes[coords.Line][coords.Col]
+	if !node.cleared {
+		return &TileError{Coords: coords, Err: ErrNotCleared}
+	}
+	b.touch(node)
+	b.setLetter(node, letter)
+	b.setCleared(node, false)
+	return nil
+}
+
 func (b *Board) ResetSwap() {
 	b.HasSwapped = false
 	b.SwappedNodes = b.SwappedNodes[:0]
 }
 
-func (b *Board) SwapNodes(node1Coords, node2Coords Coords, isReset bool) {
-	if b.HasSwapped && !isReset {
-		log.Fatalln("Cannot swap nodes after a swap has already been made")
+// Swaps two tiles, or undoes the swap if isReset is set. The rules decide which swaps are allowed.
+func (b *Board) SwapNodes(node1Coords, node2Coords Coords, isReset bool) error {
+	if !b.HasSwapped && isReset {
+		return ErrNotSwapped
 	}
 
 	node1 := b.Nodes[node1Coords.Line][node1Coords.Col]
 	node2 := b.Nodes[node2Coords.Line][node2Coords.Col]
 
-	if node1.Color == VeryBlue || node1.Color == VeryRed || node2.Color == VeryBlue || node2.Color == VeryRed {
-		log.Fatalln("Can't swap nodes that are captured")
+	if !isReset {
+		if err := b.Rules().CanSwap(b, node1, node2); err != nil {
+			return err
+		}
 	}
-	if node1.used || node2.used {
-		log.Fatalln("Cannot swap nodes that have already been used")
+	for _, node := range []*BoardNode{node1, node2} {
+		if node.used {
+			return &TileError{Coords: node.coords, Err: ErrTileUsed}
+		}
 	}
 
+	b.touch(node1)
+	b.touch(node2)
+
 	if isReset {
-		if !b.HasSwapped {
-			log.Fatalln("Cannot reset a board that has not been swapped")
-		}
 		b.HasSwapped = false
 		b.SwappedNodes = b.SwappedNodes[:0]
 		node1.IsSwapped = false
 		node2.IsSwapped = false
 	} else {
-		if b.HasSwapped {
-			log.Fatalln("Cannot swap nodes after a swap has already been made")
-		}
 		b.HasSwapped = true
 		b.SwappedNodes = append(b.SwappedNodes, node1, node2)
 		node1.IsSwapped = true
@@ -330,15 +404,29 @@ func (b *Board) SwapNodes(node1Coords, node2Coords Coords, isReset bool) {
 	tempUsed := node1.used
 	tempLetter := node1.Letter
 
-	node1.Color = node2.Color
-	node1.cleared = node2.cleared
+	b.setColor(node1, node2.Color)
+	b.setCleared(node1, node2.cleared)
 	node1.used = node2.used
-	node1.Letter = node2.Letter
+	b.setLetter(node1, node2.Letter)
 
-	node2.Color = tempColor
-	node2.cleared = tempCleared
+	b.setColor(node2, tempColor)
+	b.setCleared(node2, tempCleared)
 	node2.used = tempUsed
-	node2.Letter = tempLetter
+	b.setLetter(node2, tempLetter)
+
+	return nil
+}
+
+// Colors of the nodes, line by line.
+func (b *Board) Colors() [][]Color {
+	colors := make([][]Color, len(b.Nodes))
+	for lineNum, line := range b.Nodes {
+		colors[lineNum] = make([]Color, len(line))
+		for nodeNum, node := range line {
+			colors[lineNum][nodeNum] = node.Color
+		}
+	}
+	return colors
 }
 
 func (b *Board) String() string {
@@ -346,9 +434,7 @@ func (b *Board) String() string {
 }
 
 func (b *Board) StringWithWord(word *Word) string {
-	if chunks == nil {
-		chunks = strings.Split(boardLayout, "X")
-	}
+	chunks := b.geometry.chunks
 
 	red := color.New(color.FgRed)
 	blue := color.New(color.FgBlue)
@@ -362,7 +448,7 @@ func (b *Board) StringWithWord(word *Word) string {
 	}
 	fmt.Fprintf(&builder, "\n")
 
-	builder.Grow(len(boardLayout))
+	builder.Grow(len(b.geometry.Layout()))
 	for idx, node := range b.nodesFlat() {
 		var letter string
 		printColor := color.New(color.FgWhite)
@@ -425,30 +511,6 @@ func (b *Board) nodesFlat() []*BoardNode {
 	return nodes
 }
 
-var chunks []string
-
-const boardLayout = `
-                     ___
-                 ___/ X \___
-             ___/ X \___/ X \___
-         ___/ X \___/ X \___/ X \___
-     ___/ X \___/ X \___/ X \___/ X \___
-    / X \___/ X \___/ X \___/ X \___/ X \
-    \___/ X \___/ X \___/ X \___/ X \___/
-    / X \___/ X \___/ X \___/ X \___/ X \
-    \___/ X \___/ X \___/ X \___/ X \___/
-    / X \___/ X \___/ X \___/ X \___/ X \
-    \___/ X \___/ X \___/ X \___/ X \___/
-    / X \___/ X \___/ X \___/ X \___/ X \
-    \___/ X \___/ X \___/ X \___/ X \___/
-    / X \___/ X \___/ X \___/ X \___/ X \
-    \___/ X \___/ X \___/ X \___/ X \___/
-        \___/ X \___/ X \___/ X \___/
-            \___/ X \___/ X \___/
-                \___/ X \___/
-                    \___/
-`
-
 func (n *BoardNode) UnmarshalJSON(data []byte) error {
 	type bn BoardNode
 	node := &bn{
@@ -459,10 +521,11 @@ func (n *BoardNode) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(node.Char) > 1 || len(node.Char) == 0 {
-		return errors.New(fmt.Sprintf("Invalid character: %s", node.Char))
+	// Bad characters are reported with their position by Board.Validate.
+	node.Char = strings.ToUpper(node.Char)
+	if len(node.Char) == 1 {
+		node.Letter = node.Char[0]
 	}
-	node.Letter = node.Char[0]
 
 	*n = BoardNode(*node)
 	return nil
@@ -472,484 +535,6 @@ func (color *Color) UnmarshalJSON(b []byte) error {
 	// Define a secondary type to avoid ending up with a recursive call to json.Unmarshal
 	type C Color
 	var r *C = (*C)(color)
-	err := json.Unmarshal(b, &r)
-	if err != nil {
-		panic(err)
-	}
-	switch *color {
-	case None, Red, Blue, VeryBlue, VeryRed:
-		return nil
-	}
-	return errors.New("Invalid leave type")
-}
-
-var coords_to_neighbors = [][][][]int{
-	{ // 0
-		{ // 0
-			{1, 0},
-			{1, 1},
-			{2, 1},
-		},
-	},
-	{ // 1
-		{ // 0
-			{0, 0},
-			{2, 0},
-			{2, 1},
-			{3, 1},
-		},
-		{ // 1
-			{0, 0},
-			{2, 1},
-			{2, 2},
-			{3, 2},
-		},
-	},
-	{ // 2
-		{ // 0
-			{1, 0},
-			{3, 0},
-			{3, 1},
-			{4, 1},
-		},
-		{ // 1
-			{0, 0},
-			{1, 0},
-			{1, 1},
-			{3, 1},
-			{3, 2},
-			{4, 2},
-		},
-		{ // 2
-			{1, 1},
-			{3, 2},
-			{3, 3},
-			{4, 3},
-		},
-	},
-	{ // 3
-		{ // 0
-			{2, 0},
-			{4, 0},
-			{4, 1},
-			{5, 0},
-		},
-		{ // 1
-			{1, 0},
-			{2, 0},
-			{2, 1},
-			{4, 1},
-			{4, 2},
-			{5, 1},
-		},
-		{ // 2
-			{1, 1},
-			{2, 1},
-			{2, 2},
-			{4, 2},
-			{4, 3},
-			{5, 2},
-		},
-		{ // 3
-			{2, 2},
-			{4, 3},
-			{4, 4},
-			{5, 3},
-		},
-	},
-	{ // 4
-		{ // 0
-			{3, 0},
-			{5, 0},
-			{6, 0},
-		},
-		{ // 1
-			{2, 0},
-			{3, 0},
-			{3, 1},
-			{5, 0},
-			{5, 1},
-			{6, 1},
-		},
-		{ // 2
-			{2, 1},
-			{3, 1},
-			{3, 2},
-			{5, 1},
-			{5, 2},
-			{6, 2},
-		},
-		{ // 3
-			{2, 2},
-			{3, 2},
-			{3, 3},
-			{5, 2},
-			{5, 3},
-			{6, 3},
-		},
-		{ // 4
-			{3, 3},
-			{5, 3},
-			{6, 4},
-		},
-	},
-	{ // 5
-		{ // 0
-			{3, 0},
-			{4, 0},
-			{4, 1},
-			{6, 0},
-			{6, 1},
-			{7, 0},
-		},
-		{ // 1
-			{3, 1},
-			{4, 1},
-			{4, 2},
-			{6, 1},
-			{6, 2},
-			{7, 1},
-		},
-		{ // 2
-			{3, 2},
-			{4, 2},
-			{4, 3},
-			{6, 2},
-			{6, 3},
-			{7, 2},
-		},
-		{ // 3
-			{3, 3},
-			{4, 3},
-			{4, 4},
-			{6, 3},
-			{6, 4},
-			{7, 3},
-		},
-	},
-	{ // 6
-		{ // 0
-			{4, 0},
-			{5, 0},
-			{7, 0},
-			{8, 0},
-		},
-		{ // 1
-			{4, 1},
-			{5, 0},
-			{5, 1},
-			{7, 0},
-			{7, 1},
-			{8, 1},
-		},
-		{ // 2
-			{4, 2},
-			{5, 1},
-			{5, 2},
-			{7, 1},
-			{7, 2},
-			{8, 2},
-		},
-		{ // 3
-			{4, 3},
-			{5, 2},
-			{5, 3},
-			{7, 2},
-			{7, 3},
-			{8, 3},
-		},
-		{ // 4
-			{4, 4},
-			{5, 3},
-			{7, 3},
-			{8, 4},
-		},
-	},
-	{ // 7
-		{ // 0
-			{5, 0},
-			{6, 0},
-			{6, 1},
-			{8, 0},
-			{8, 1},
-			{9, 0},
-		},
-		{ // 1
-			{5, 1},
-			{6, 1},
-			{6, 2},
-			{8, 1},
-			{8, 2},
-			{9, 1},
-		},
-		{ // 2
-			{5, 2},
-			{6, 2},
-			{6, 3},
-			{8, 2},
-			{8, 3},
-			{9, 2},
-		},
-		{ // 3
-			{5, 3},
-			{6, 3},
-			{6, 4},
-			{8, 3},
-			{8, 4},
-			{9, 3},
-		},
-	},
-	{ // 8
-		{ // 0
-			{6, 0},
-			{7, 0},
-			{9, 0},
-			{10, 0},
-		},
-		{ // 1
-			{6, 1},
-			{7, 0},
-			{7, 1},
-			{9, 0},
-			{9, 1},
-			{10, 1},
-		},
-		{ // 2
-			{6, 2},
-			{7, 1},
-			{7, 2},
-			{9, 1},
-			{9, 2},
-			{10, 2},
-		},
-		{ // 3
-			{6, 3},
-			{7, 2},
-			{7, 3},
-			{9, 2},
-			{9, 3},
-			{10, 3},
-		},
-		{ // 4
-			{6, 4},
-			{7, 3},
-			{9, 3},
-			{10, 4},
-		},
-	},
-	{ // 9
-		{ // 0
-			{7, 0},
-			{8, 0},
-			{8, 1},
-			{10, 0},
-			{10, 1},
-			{11, 0},
-		},
-		{ // 1
-			{7, 1},
-			{8, 1},
-			{8, 2},
-			{10, 1},
-			{10, 2},
-			{11, 1},
-		},
-		{ // 2
-			{7, 2},
-			{8, 2},
-			{8, 3},
-			{10, 2},
-			{10, 3},
-			{11, 2},
-		},
-		{ // 3
-			{7, 3},
-			{8, 3},
-			{8, 4},
-			{10, 3},
-			{10, 4},
-			{11, 3},
-		},
-	},
-	{ // 10
-		{ // 0
-			{8, 0},
-			{9, 0},
-			{11, 0},
-			{12, 0},
-		},
-		{ // 1
-			{8, 1},
-			{9, 0},
-			{9, 1},
-			{11, 0},
-			{11, 1},
-			{12, 1},
-		},
-		{ // 2
-			{8, 2},
-			{9, 1},
-			{9, 2},
-			{11, 1},
-			{11, 2},
-			{12, 2},
-		},
-		{ // 3
-			{8, 3},
-			{9, 2},
-			{9, 3},
-			{11, 2},
-			{11, 3},
-			{12, 3},
-		},
-		{ // 4
-			{8, 4},
-			{9, 3},
-			{11, 3},
-			{12, 4},
-		},
-	},
-	{ // 11
-		{ // 0
-			{9, 0},
-			{10, 0},
-			{10, 1},
-			{12, 0},
-			{12, 1},
-			{13, 0},
-		},
-		{ // 1
-			{9, 1},
-			{10, 1},
-			{10, 2},
-			{12, 1},
-			{12, 2},
-			{13, 1},
-		},
-		{ // 2
-			{9, 2},
-			{10, 2},
-			{10, 3},
-			{12, 2},
-			{12, 3},
-			{13, 2},
-		},
-		{ // 3
-			{9, 3},
-			{10, 3},
-			{10, 4},
-			{12, 3},
-			{12, 4},
-			{13, 3},
-		},
-	},
-	{ // 12
-		{ // 0
-			{10, 0},
-			{11, 0},
-			{13, 0},
-		},
-		{ // 1
-			{10, 1},
-			{11, 0},
-			{11, 1},
-			{13, 0},
-			{13, 1},
-			{14, 0},
-		},
-		{ // 2
-			{10, 2},
-			{11, 1},
-			{11, 2},
-			{13, 1},
-			{13, 2},
-			{14, 1},
-		},
-		{ // 3
-			{10, 3},
-			{11, 2},
-			{11, 3},
-			{13, 2},
-			{13, 3},
-			{14, 2},
-		},
-		{ // 4
-			{10, 4},
-			{11, 3},
-			{13, 3},
-		},
-	},
-	{ // 13
-		{ // 0
-			{11, 0},
-			{12, 0},
-			{12, 1},
-			{14, 0},
-		},
-		{ // 1
-			{11, 1},
-			{12, 1},
-			{12, 2},
-			{14, 0},
-			{14, 1},
-			{15, 0},
-		},
-		{ // 2
-			{11, 2},
-			{12, 2},
-			{12, 3},
-			{14, 1},
-			{14, 2},
-			{15, 1},
-		},
-		{ // 3
-			{11, 3},
-			{12, 3},
-			{12, 4},
-			{14, 2},
-		},
-	},
-	{ // 14
-		{ // 0
-			{12, 1},
-			{13, 0},
-			{13, 1},
-			{15, 0},
-		},
-		{ // 1
-			{12, 2},
-			{13, 1},
-			{13, 2},
-			{15, 0},
-			{15, 1},
-			{16, 0},
-		},
-		{ // 2
-			{12, 3},
-			{13, 2},
-			{13, 3},
-			{15, 1},
-		},
-	},
-	{ // 15
-		{ // 0
-			{13, 1},
-			{14, 0},
-			{14, 1},
-			{16, 0},
-		},
-		{ // 1
-			{13, 2},
-			{14, 1},
-			{14, 2},
-			{16, 0},
-		},
-	},
-	{ // 16
-		{ // 0
-			{14, 1},
-			{15, 0},
-			{15, 1},
-		},
-	},
+	// Unknown colors are reported with their position by Board.Validate.
+	return json.Unmarshal(b, &r)
 }
@@ -0,0 +1,87 @@
+package main
+
+// nodeState is everything a move can change about a node.
+type nodeState struct {
+	node      *BoardNode
+	letter    byte
+	char      string
+	color     Color
+	cleared   bool
+	isSwapped bool
+}
+
+// MoveDelta records the changes MakeMove made to a board, so UnmakeMove can
+// revert them instead of the search keeping a copy of the board for every
+// move.
+type MoveDelta struct {
+	score        BoardScore
+	hash         uint64
+	hasSwapped   bool
+	swappedNodes []*BoardNode
+	// Previous states of the nodes the move changed, in the order they were changed.
+	changes []nodeState
+	// Centers of the hexagons the move captured.
+	Hexagons []Coords
+}
+
+// MakeMove plays the word, including its swap, and returns the delta needed
+// to undo it. The board is left untouched if the move is invalid.
+func (b *Board) MakeMove(word *Word, mover Mover) (*MoveDelta, error) {
+	delta := &MoveDelta{
+		score:        b.Score,
+		hash:         b.hash,
+		hasSwapped:   b.HasSwapped,
+		swappedNodes: append([]*BoardNode{}, b.SwappedNodes...),
+		changes:      make([]nodeState, 0, len(word.letters)+2),
+	}
+	b.journal = delta
+	defer func() {
+		b.journal = nil
+	}()
+
+	if len(word.SwappedNodes) > 0 {
+		if err := b.SwapNodes(word.SwappedNodes[0], word.SwappedNodes[1], false); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.Play(word, mover); err != nil {
+		b.UnmakeMove(delta)
+		return nil, err
+	}
+	return delta, nil
+}
+
+// UnmakeMove restores the board to how it was before the MakeMove call that returned the delta.
+func (b *Board) UnmakeMove(delta *MoveDelta) {
+	for idx := len(delta.changes) - 1; idx >= 0; idx-- {
+		change := delta.changes[idx]
+		change.node.Letter = change.letter
+		change.node.Char = change.char
+		change.node.Color = change.color
+		change.node.cleared = change.cleared
+		change.node.IsSwapped = change.isSwapped
+		b.updateBits(change.node)
+	}
+	b.Score = delta.score
+	if b.bits != nil {
+		b.bits.Score = delta.score
+	}
+	b.hash = delta.hash
+	b.HasSwapped = delta.hasSwapped
+	b.SwappedNodes = append(b.SwappedNodes[:0], delta.swappedNodes...)
+}
+
+// touch records the state of a node before it is changed, if a move is being made.
+func (b *Board) touch(node *BoardNode) {
+	if b.journal == nil {
+		return
+	}
+	b.journal.changes = append(b.journal.changes, nodeState{
+		node:      node,
+		letter:    node.Letter,
+		char:      node.Char,
+		color:     node.Color,
+		cleared:   node.cleared,
+		isSwapped: node.IsSwapped,
+	})
+}
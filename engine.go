@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Engine picks a move on the board, or returns nil if there are none.
+type Engine func(board *Board, trie *Trie, options SearchOptions, table *TranspositionTable) *Move
+
+// Engines that can be selected by name.
+var Engines = map[string]Engine{
+	"minimax": ExecuteMinimax,
+	"mcts":    ExecuteMCTS,
+}
+
+func GetEngine(name string) (Engine, error) {
+	engine, ok := Engines[name]
+	if !ok {
+		names := make([]string, 0, len(Engines))
+		for name := range Engines {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown engine %q, expected one of %v", name, names)
+	}
+	return engine, nil
+}
+
+func (o SearchOptions) evaluator(trie *Trie) Evaluator {
+	if o.Evaluator == nil {
+		return DefaultEvaluator(trie)
+	}
+	return o.Evaluator
+}
+
+// SearchOptions bound how deep and how long an engine searches, and tune how
+// it searches. Engines ignore the options that don't apply to them.
+type SearchOptions struct {
+	// Deepest iteration of minimax. If zero, it is DEPTH without a time limit
+	// and MAX_DEPTH with one.
+	Depth int
+	// Time budget of the search, zero for no limit.
+	Time time.Duration
+	// Evaluates the positions where the search stops, DefaultEvaluator if nil.
+	Evaluator Evaluator
+	// Number of moves at the root minimax searches in parallel, at least 1.
+	Threads int
+	// Number of best moves minimax finds the principal variations of, see ExecuteMultiPV.
+	MultiPV int
+
+	// Iterations of MCTS. If zero, it is MCTS_ITERATIONS without a time limit
+	// and unlimited with one.
+	Iterations int
+	// Weight of the exploration term of UCT, MCTS_EXPLORATION if zero.
+	Exploration float64
+	// How MCTS plays games out: RandomPlayout (the default) or GreedyPlayout.
+	Playout string
+	// Seed of the random numbers used by MCTS.
+	Seed int64
+
+	// If set, the engine adds the counts of its search to the stats.
+	Stats *SearchStats
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Evaluator estimates how likely a side is to win a position that isn't
+// over, between 0 and 1. The search calls it where it stops searching.
+type Evaluator interface {
+	Evaluate(board *Board, side Mover) float64
+}
+
+// Feature measures something about a position from the point of view of the
+// side of the evaluation, between 0 (bad for the side) and 1 (good for the side).
+type Feature struct {
+	Name    string
+	Measure func(e *Evaluation) float64
+	// Makes the measure of features that look for words, for a dictionary.
+	// It is set instead of Measure.
+	WithTrie func(trie *Trie) func(e *Evaluation) float64
+}
+
+// Measurer returns the measure of the feature, which looks words up in the
+// trie if the feature needs it.
+func (f Feature) Measurer(trie *Trie) func(e *Evaluation) float64 {
+	if f.WithTrie != nil {
+		return f.WithTrie(trie)
+	}
+	return f.Measure
+}
+
+// Evaluation is a position being evaluated from a side's point of view. It
+// keeps the counts that several features share, so that they are computed
+// once per evaluation.
+type Evaluation struct {
+	Board *Board
+	Side  Mover
+
+	hasNeighbors bool
+	neighbors    neighborCounts
+	hasThreats   bool
+	// Threats of the side and of its opponent.
+	threats [2]HexagonThreats
+}
+
+func NewEvaluation(board *Board, side Mover) *Evaluation {
+	return &Evaluation{Board: board, Side: side}
+}
+
+// Features that can be weighed by a LinearEvaluator, in the order they are added up.
+var Features = []Feature{
+	{Name: "closenessToWinning", Measure: closenessToWinning},
+	{Name: "closenessToLosing", Measure: closenessToLosing},
+	{Name: "ownNeighbors", Measure: ownNeighbors},
+	{Name: "opponentNeighbors", Measure: opponentNeighbors},
+	{Name: "ownHexagonThreats", Measure: ownHexagonThreats},
+	{Name: "opponentHexagonThreats", Measure: opponentHexagonThreats},
+	{Name: "superHexagonPotential", Measure: superHexagonPotential},
+	{Name: "ownMobility", WithTrie: ownMobility},
+	{Name: "opponentMobility", WithTrie: opponentMobility},
+}
+
+// Score of each side, the side's first.
+func sideScores(board *Board, side Mover) (int, int) {
+	if side == RedMover {
+		return board.Score.Red, board.Score.Blue
+	}
+	return board.Score.Blue, board.Score.Red
+}
+
+// How close the side is to the winning score.
+func closenessToWinning(e *Evaluation) float64 {
+	ownScore, _ := sideScores(e.Board, e.Side)
+	return float64(ownScore) / float64(e.Board.Rules().WinningScore())
+}
+
+// How far the opponent is from the winning score.
+func closenessToLosing(e *Evaluation) float64 {
+	_, opponentScore := sideScores(e.Board, e.Side)
+	return 1 - float64(opponentScore)/float64(e.Board.Rules().WinningScore())
+}
+
+// Neighbors of every tile that belong to a side and to its opponent.
+type neighborCounts struct {
+	own      int
+	opponent int
+	total    int
+}
+
+func countNeighbors(board *Board, side Mover) neighborCounts {
+	counts := neighborCounts{}
+	for _, node := range board.nodesFlat() {
+		for _, neighbor := range board.GetNeighbors(node) {
+			counts.total++
+			if neighbor.Color == None {
+				continue
+			}
+			if side.IsMatching(neighbor.Color) {
+				counts.own++
+			} else {
+				counts.opponent++
+			}
+		}
+	}
+	return counts
+}
+
+func (e *Evaluation) neighborCounts() neighborCounts {
+	if !e.hasNeighbors {
+		e.neighbors = countNeighbors(e.Board, e.Side)
+		e.hasNeighbors = true
+	}
+	return e.neighbors
+}
+
+// Share of the neighbors of tiles that belong to the side, weighing tiles by
+// how many neighbors they have.
+func ownNeighbors(e *Evaluation) float64 {
+	counts := e.neighborCounts()
+	return float64(counts.own) / float64(counts.total)
+}
+
+// Share of the neighbors of tiles that don't belong to the opponent.
+func opponentNeighbors(e *Evaluation) float64 {
+	counts := e.neighborCounts()
+	return 1 - float64(counts.opponent)/float64(counts.total)
+}
+
+// LinearEvaluator adds up features multiplied by their weights. The weights
+// should add up to 1 to keep the score between 0 and 1.
+type LinearEvaluator struct {
+	measures []func(e *Evaluation) float64
+	weights  []float64
+}
+
+// Weights fitted by the tune command to 200 games at depth 1. In 400 games
+// at depth 1 against the weights of the original heuristic (closeness to
+// winning .6, to losing .15, own neighbors .2, opponent neighbors .05) they
+// scored 57%. The mobility features are left out: the fit gave them almost no
+// weight, and counting words at every leaf is slow. The solver reads its
+// weights from DEFAULT_EVALUATOR_CONFIG and only falls back to these if the
+// file is missing.
+var DefaultWeights = map[string]float64{
+	"closenessToWinning":     .2425,
+	"closenessToLosing":      .2425,
+	"ownNeighbors":           .175,
+	"opponentNeighbors":      .175,
+	"ownHexagonThreats":      .019,
+	"opponentHexagonThreats": .019,
+	"superHexagonPotential":  .125,
+}
+
+func NewLinearEvaluator(weights map[string]float64, trie *Trie) (Evaluator, error) {
+	evaluator := &LinearEvaluator{}
+	known := map[string]bool{}
+	for _, feature := range Features {
+		known[feature.Name] = true
+		if weight := weights[feature.Name]; weight != 0 {
+			evaluator.measures = append(evaluator.measures, feature.Measurer(trie))
+			evaluator.weights = append(evaluator.weights, weight)
+		}
+	}
+	for name := range weights {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown feature %q", name)
+		}
+	}
+	return evaluator, nil
+}
+
+func (e *LinearEvaluator) Evaluate(board *Board, side Mover) float64 {
+	evaluation := NewEvaluation(board, side)
+	result := 0.0
+	for idx, measure := range e.measures {
+		result += measure(evaluation) * e.weights[idx]
+	}
+	return result
+}
+
+// EvaluatorFactory makes an evaluator with the weights of a config and the dictionary.
+type EvaluatorFactory func(weights map[string]float64, trie *Trie) (Evaluator, error)
+
+// Evaluators that can be selected by name in an EvaluatorConfig.
+var Evaluators = map[string]EvaluatorFactory{
+	"linear": NewLinearEvaluator,
+}
+
+// RegisterEvaluator makes an evaluator available to configs under the name.
+func RegisterEvaluator(name string, factory EvaluatorFactory) {
+	Evaluators[name] = factory
+}
+
+// DefaultEvaluator is the evaluator used when the search isn't given one: a
+// LinearEvaluator with DefaultWeights, looking words up in the trie.
+func DefaultEvaluator(trie *Trie) Evaluator {
+	evaluator, err := NewLinearEvaluator(DefaultWeights, trie)
+	if err != nil {
+		panic(err)
+	}
+	return evaluator
+}
+
+// EvaluatorConfig selects an evaluator and its weights, stored as JSON:
+//
+//	{"evaluator": "linear", "weights": {"closenessToWinning": 0.6, ...}}
+type EvaluatorConfig struct {
+	// Name of the evaluator in Evaluators, "linear" if empty.
+	Evaluator string             `json:"evaluator"`
+	Weights   map[string]float64 `json:"weights"`
+}
+
+// NewEvaluator makes the evaluator the config selects, looking words up in the trie.
+func (c *EvaluatorConfig) NewEvaluator(trie *Trie) (Evaluator, error) {
+	name := c.Evaluator
+	if name == "" {
+		name = "linear"
+	}
+	factory, ok := Evaluators[name]
+	if !ok {
+		names := make([]string, 0, len(Evaluators))
+		for name := range Evaluators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown evaluator %q, expected one of %v", name, names)
+	}
+	return factory(c.Weights, trie)
+}
+
+// Config the solver loads its evaluator from, unless -eval names another one.
+const DEFAULT_EVALUATOR_CONFIG = "evaluator.json"
+
+// LoadDefaultEvaluatorConfig loads DEFAULT_EVALUATOR_CONFIG, or falls back to
+// the linear evaluator with DefaultWeights if the file doesn't exist.
+func LoadDefaultEvaluatorConfig() (*EvaluatorConfig, error) {
+	config, err := LoadEvaluatorConfig(DEFAULT_EVALUATOR_CONFIG)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &EvaluatorConfig{Evaluator: "linear", Weights: DefaultWeights}, nil
+	}
+	return config, err
+}
+
+func LoadEvaluatorConfig(path string) (*EvaluatorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &EvaluatorConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c *EvaluatorConfig) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Refill is a letter the game dealt into a cleared tile after a move.
+type Refill struct {
+	Coords Coords `json:"coords"`
+	Char   string `json:"char"`
+}
+
+// GameMove is a move of a recorded game, along with the state of the board after it.
+type GameMove struct {
+	Mover Mover    `json:"mover"`
+	Path  []Coords `json:"path"`
+	// The two tiles swapped before the path was played, if any.
+	Swap  []Coords   `json:"swap,omitempty"`
+	Word  string     `json:"word"`
+	Score BoardScore `json:"score"`
+	// Colors of the board after the move, before refills. Replay skips the check if they weren't recorded.
+	Colors  [][]Color `json:"colors,omitempty"`
+	Refills []Refill  `json:"refills,omitempty"`
+}
+
+// Game is the record of a game: the board it started from and every move played since.
+type Game struct {
+	// Free-form information about the game, such as who played it.
+	Headers map[string]string `json:"headers,omitempty"`
+	Board   *Board            `json:"board"`
+	Moves   []*GameMove       `json:"moves"`
+}
+
+var (
+	ErrScoreMismatch  = errors.New("score does not match the recorded score")
+	ErrColorsMismatch = errors.New("colors do not match the recorded colors")
+)
+
+// ReplayError is returned when a move of a game can't be replayed.
+type ReplayError struct {
+	Move int
+	Err  error
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("move %d: %s", e.Move+1, e.Err)
+}
+
+func (e *ReplayError) Unwrap() error {
+	return e.Err
+}
+
+// NewGame starts the record of a game played from the board.
+func NewGame(board *Board) *Game {
+	return &Game{Board: board.clone(), Moves: []*GameMove{}}
+}
+
+// Record adds a move to the game. The board is the board after the move was played.
+func (g *Game) Record(move *Move, board *Board) *GameMove {
+	gameMove := &GameMove{
+		Mover:  move.Mover,
+		Path:   make([]Coords, 0, len(move.word.letters)),
+		Swap:   move.word.SwappedNodes,
+		Word:   move.word.String(),
+		Score:  board.Score,
+		Colors: board.Colors(),
+	}
+	for _, letter := range move.word.letters {
+		gameMove.Path = append(gameMove.Path, letter.coords)
+	}
+	g.Moves = append(g.Moves, gameMove)
+	return gameMove
+}
+
+// Move converts the record back to a move that can be played.
+func (m *GameMove) Move() (*Move, error) {
+	return NewMove(m.Mover, m.Path, m.Swap, m.Word)
+}
+
+// Replay plays every move of the game from the starting board, checking that
+// each one is legal and leads to the recorded score and colors. It returns the
+// board at the end of the game.
+func (g *Game) Replay(trie *Trie) (*Board, error) {
+	board := g.Board.clone()
+	for idx, gameMove := range g.Moves {
+		move, err := gameMove.Move()
+		if err != nil {
+			return board, &ReplayError{Move: idx, Err: err}
+		}
+		if err := ValidateMove(board, move, gameMove.Mover, trie); err != nil {
+			return board, &ReplayError{Move: idx, Err: err}
+		}
+		if _, err := board.MakeMove(move.word, gameMove.Mover); err != nil {
+			return board, &ReplayError{Move: idx, Err: err}
+		}
+
+		if board.Score != gameMove.Score {
+			return board, &ReplayError{Move: idx, Err: fmt.Errorf("%w: got %d / %d, recorded %d / %d", ErrScoreMismatch, board.Score.Blue, board.Score.Red, gameMove.Score.Blue, gameMove.Score.Red)}
+		}
+		if gameMove.Colors != nil {
+			if coords, found := firstColorMismatch(board, gameMove.Colors); found {
+				return board, &ReplayError{Move: idx, Err: fmt.Errorf("%w at %d,%d", ErrColorsMismatch, coords.Line, coords.Col)}
+			}
+		}
+
+		for _, refill := range gameMove.Refills {
+			if !board.geometry.IsValid(refill.Coords) || len(refill.Char) != 1 {
+				return board, &ReplayError{Move: idx, Err: fmt.Errorf("invalid refill %v %q", refill.Coords, refill.Char)}
+			}
+			if err := board.Refill(refill.Coords, refill.Char[0]); err != nil {
+				return board, &ReplayError{Move: idx, Err: err}
+			}
+		}
+	}
+	return board, nil
+}
+
+// Returns the first node whose color differs from the recorded colors, and true if there is one.
+func firstColorMismatch(board *Board, colors [][]Color) (Coords, bool) {
+	if len(colors) != len(board.Nodes) {
+		return Coords{}, true
+	}
+	for lineNum, line := range board.Nodes {
+		if len(colors[lineNum]) != len(line) {
+			return Coords{Line: lineNum}, true
+		}
+		for nodeNum, node := range line {
+			if node.Color != colors[lineNum][nodeNum] {
+				return Coords{Line: lineNum, Col: nodeNum}, true
+			}
+		}
+	}
+	return Coords{}, false
+}
+
+func (g *Game) UnmarshalJSON(data []byte) error {
+	// Define a secondary type to avoid ending up with a recursive call to json.Unmarshal
+	type game Game
+	if err := json.Unmarshal(data, (*game)(g)); err != nil {
+		return err
+	}
+	if g.Board == nil {
+		return errors.New("game has no board")
+	}
+	if err := g.Board.Validate(); err != nil {
+		return err
+	}
+	g.Board.Initialize()
+	return nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The standard Hexicon board: a hexagon with 5 cells on each side, 61 cells in total.
+const BOARD_RADIUS = 4
+
+// Hex is the position of a cell in axial coordinates. The third cube
+// coordinate is implied by Q + R + S = 0.
+//
+// The board is drawn with flat-topped cells, so Q is the column and R runs
+// down and to the left. The center cell of the board is the origin.
+type Hex struct {
+	Q int
+	R int
+}
+
+var hexDirections = [6]Hex{
+	{Q: 0, R: -1},
+	{Q: 1, R: -1},
+	{Q: 1, R: 0},
+	{Q: 0, R: 1},
+	{Q: -1, R: 1},
+	{Q: -1, R: 0},
+}
+
+func (h Hex) S() int {
+	return -h.Q - h.R
+}
+
+func (h Hex) Add(other Hex) Hex {
+	return Hex{Q: h.Q + other.Q, R: h.R + other.R}
+}
+
+func (h Hex) Scale(factor int) Hex {
+	return Hex{Q: h.Q * factor, R: h.R * factor}
+}
+
+func (h Hex) Neighbor(direction int) Hex {
+	return h.Add(hexDirections[direction%6])
+}
+
+func (h Hex) Distance(other Hex) int {
+	return (abs(h.Q-other.Q) + abs(h.R-other.R) + abs(h.S()-other.S())) / 2
+}
+
+// Ring returns the cells at exactly the given distance from h, walking
+// around the ring. A ring of radius 0 is h itself.
+func (h Hex) Ring(radius int) []Hex {
+	if radius == 0 {
+		return []Hex{h}
+	}
+	ring := make([]Hex, 0, 6*radius)
+	current := h.Add(hexDirections[4].Scale(radius))
+	for direction := 0; direction < 6; direction++ {
+		for step := 0; step < radius; step++ {
+			ring = append(ring, current)
+			current = current.Neighbor(direction)
+		}
+	}
+	return ring
+}
+
+// Geometry describes the shape of a hexagonal board with the given radius and
+// maps between axial coordinates and the Line/Col coordinates used by the
+// board JSON.
+//
+// Lines zig-zag across the board: a cell's line is its doubled row, so
+// horizontally adjacent columns alternate between even and odd lines.
+type Geometry struct {
+	Radius    int
+	lines     [][]Hex
+	coords    map[Hex]Coords
+	neighbors [][][]Coords
+	layout    string
+	chunks    []string
+	masks     *geometryMasks
+}
+
+var geometriesMutex sync.Mutex
+var geometries = map[int]*Geometry{}
+
+// GetGeometry returns the (shared, read-only) geometry for a board radius.
+func GetGeometry(radius int) *Geometry {
+	geometriesMutex.Lock()
+	defer geometriesMutex.Unlock()
+
+	if geometry, ok := geometries[radius]; ok {
+		return geometry
+	}
+	geometry := newGeometry(radius)
+	geometries[radius] = geometry
+	return geometry
+}
+
+// Radius of the board that has the given number of lines, or -1 if no board has that many lines.
+func radiusForLines(numLines int) int {
+	if numLines < 1 || (numLines-1)%4 != 0 {
+		return -1
+	}
+	return (numLines - 1) / 4
+}
+
+func newGeometry(radius int) *Geometry {
+	g := &Geometry{
+		Radius: radius,
+		lines:  make([][]Hex, 4*radius+1),
+		coords: map[Hex]Coords{},
+	}
+
+	// Walk the columns from left to right so every line ends up sorted by column.
+	for q := -radius; q <= radius; q++ {
+		for r := -radius; r <= radius; r++ {
+			hex := Hex{Q: q, R: r}
+			if !g.Contains(hex) {
+				continue
+			}
+			line := g.lineOf(hex)
+			g.coords[hex] = Coords{Line: line, Col: len(g.lines[line])}
+			g.lines[line] = append(g.lines[line], hex)
+		}
+	}
+
+	g.neighbors = make([][][]Coords, len(g.lines))
+	for lineNum, line := range g.lines {
+		g.neighbors[lineNum] = make([][]Coords, len(line))
+		for col, hex := range line {
+			neighbors := make([]Coords, 0, 6)
+			for direction := 0; direction < 6; direction++ {
+				if coords, ok := g.coords[hex.Neighbor(direction)]; ok {
+					neighbors = append(neighbors, coords)
+				}
+			}
+			sortCoords(neighbors)
+			g.neighbors[lineNum][col] = neighbors
+		}
+	}
+
+	g.layout = g.buildLayout()
+	g.chunks = strings.Split(g.layout, "X")
+	g.masks = g.buildMasks()
+
+	return g
+}
+
+func (g *Geometry) lineOf(hex Hex) int {
+	return 2*hex.R + hex.Q + 2*g.Radius
+}
+
+func (g *Geometry) columnOf(hex Hex) int {
+	return hex.Q + g.Radius
+}
+
+func (g *Geometry) Contains(hex Hex) bool {
+	return abs(hex.Q) <= g.Radius && abs(hex.R) <= g.Radius && abs(hex.S()) <= g.Radius
+}
+
+func (g *Geometry) NumLines() int {
+	return len(g.lines)
+}
+
+func (g *Geometry) LineLength(line int) int {
+	return len(g.lines[line])
+}
+
+func (g *Geometry) NumCells() int {
+	return len(g.coords)
+}
+
+func (g *Geometry) IsValid(coords Coords) bool {
+	return coords.Line >= 0 && coords.Line < len(g.lines) && coords.Col >= 0 && coords.Col < len(g.lines[coords.Line])
+}
+
+func (g *Geometry) Hex(coords Coords) Hex {
+	return g.lines[coords.Line][coords.Col]
+}
+
+func (g *Geometry) Coords(hex Hex) (Coords, bool) {
+	coords, ok := g.coords[hex]
+	return coords, ok
+}
+
+// Index of the cell in line order, matching Board.nodesFlat.
+func (g *Geometry) Index(coords Coords) int {
+	index := coords.Col
+	for line := 0; line < coords.Line; line++ {
+		index += len(g.lines[line])
+	}
+	return index
+}
+
+// Neighbors of a cell, sorted by line and then column.
+func (g *Geometry) Neighbors(coords Coords) []Coords {
+	return g.neighbors[coords.Line][coords.Col]
+}
+
+func (g *Geometry) Distance(a, b Coords) int {
+	return g.Hex(a).Distance(g.Hex(b))
+}
+
+// Ring returns the cells on the board at exactly the given distance from a
+// cell, sorted by line and then column.
+func (g *Geometry) Ring(center Coords, radius int) []Coords {
+	ring := []Coords{}
+	for _, hex := range g.Hex(center).Ring(radius) {
+		if coords, ok := g.coords[hex]; ok {
+			ring = append(ring, coords)
+		}
+	}
+	sortCoords(ring)
+	return ring
+}
+
+// IsHexagonCenter reports whether all six neighbors of the cell are on the
+// board, i.e. whether the cell is the center of a 7-cell hexagon.
+func (g *Geometry) IsHexagonCenter(coords Coords) bool {
+	return len(g.Neighbors(coords)) == 6
+}
+
+// Hexagons returns the centers of the 7-cell hexagons that contain the cell.
+func (g *Geometry) Hexagons(coords Coords) []Coords {
+	centers := []Coords{}
+	if g.IsHexagonCenter(coords) {
+		centers = append(centers, coords)
+	}
+	for _, neighbor := range g.Neighbors(coords) {
+		if g.IsHexagonCenter(neighbor) {
+			centers = append(centers, neighbor)
+		}
+	}
+	sortCoords(centers)
+	return centers
+}
+
+// The ASCII art for the board, with an X in place of each letter.
+func (g *Geometry) Layout() string {
+	return g.layout
+}
+
+func (g *Geometry) buildLayout() string {
+	const margin = 4
+	width := margin + 8*g.Radius + 5
+	rows := make([][]byte, len(g.lines)+2)
+	for idx := range rows {
+		rows[idx] = []byte(strings.Repeat(" ", width))
+	}
+
+	for lineNum, line := range g.lines {
+		for _, hex := range line {
+			left := margin + 4*g.columnOf(hex)
+			copy(rows[lineNum][left+1:], "___")
+			copy(rows[lineNum+1][left:], "/ X \\")
+			copy(rows[lineNum+2][left:], "\\___/")
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\n")
+	for _, row := range rows {
+		builder.WriteString(strings.TrimRight(string(row), " "))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func sortCoords(coords []Coords) {
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i].Line != coords[j].Line {
+			return coords[i].Line < coords[j].Line
+		}
+		return coords[i].Col < coords[j].Col
+	})
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
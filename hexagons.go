@@ -0,0 +1,143 @@
+package main
+
+// Value of hexagon threats (see HexagonThreats.value) at which the hexagon
+// features stop growing. A side rarely has more threats than this, and one
+// capture per move is what matters most.
+const MAX_HEXAGON_THREATS = 4
+
+// HexagonThreats counts the hexagons a side could capture by coloring one or
+// two more tiles. A hexagon is captured once all seven of its tiles are
+// colored, by the side with the most of them, so coloring the missing tiles
+// captures it if the side would then have the majority.
+type HexagonThreats struct {
+	OneAway int
+	TwoAway int
+	// Threats whose missing tiles all have letters, so a word can color them
+	// now. Cleared tiles have to be refilled first.
+	ReachableOneAway int
+	ReachableTwoAway int
+}
+
+// FindHexagonThreats counts the hexagons each side threatens to capture, in
+// one walk over the board.
+func (b *Board) FindHexagonThreats() (blue HexagonThreats, red HexagonThreats) {
+	for _, center := range b.nodesFlat() {
+		neighbors := b.GetNeighbors(center)
+		// Captured centers can't be captured again.
+		if len(neighbors) != 6 || center.Color == VeryRed || center.Color == VeryBlue {
+			continue
+		}
+		blueCount, redCount, missing := 0, 0, 0
+		reachable := true
+		for _, node := range append([]*BoardNode{center}, neighbors...) {
+			switch {
+			case node.Color == None:
+				missing++
+				if node.cleared {
+					reachable = false
+				}
+			case node.Color == Blue || node.Color == VeryBlue:
+				blueCount++
+			default:
+				redCount++
+			}
+		}
+		if blueCount+missing > redCount {
+			blue.add(missing, reachable)
+		}
+		if redCount+missing > blueCount {
+			red.add(missing, reachable)
+		}
+	}
+	return blue, red
+}
+
+// Counts a hexagon missing tiles away from being captured.
+func (t *HexagonThreats) add(missing int, reachable bool) {
+	switch missing {
+	case 1:
+		t.OneAway++
+		if reachable {
+			t.ReachableOneAway++
+		}
+	case 2:
+		t.TwoAway++
+		if reachable {
+			t.ReachableTwoAway++
+		}
+	}
+}
+
+// Threats of the side of the evaluation, or of its opponent.
+func (e *Evaluation) hexagonThreats(opponent bool) HexagonThreats {
+	if !e.hasThreats {
+		blue, red := e.Board.FindHexagonThreats()
+		if e.Side == RedMover {
+			blue, red = red, blue
+		}
+		e.threats = [2]HexagonThreats{blue, red}
+		e.hasThreats = true
+	}
+	if opponent {
+		return e.threats[1]
+	}
+	return e.threats[0]
+}
+
+// Value of the threats, counting a threat two tiles away as half of one a
+// tile away, and a threat that needs refills as half of a reachable one.
+func (t HexagonThreats) value() float64 {
+	return float64(t.ReachableOneAway) + 0.5*float64(t.OneAway-t.ReachableOneAway) +
+		0.5*float64(t.ReachableTwoAway) + 0.25*float64(t.TwoAway-t.ReachableTwoAway)
+}
+
+func numHexagonCenters(board *Board) int {
+	count := 0
+	for _, node := range board.nodesFlat() {
+		if len(board.GetNeighbors(node)) == 6 {
+			count++
+		}
+	}
+	return count
+}
+
+// How many hexagons the side threatens to capture.
+func ownHexagonThreats(e *Evaluation) float64 {
+	return min(e.hexagonThreats(false).value()/MAX_HEXAGON_THREATS, 1)
+}
+
+// How few hexagons the opponent threatens to capture.
+func opponentHexagonThreats(e *Evaluation) float64 {
+	return 1 - min(e.hexagonThreats(true).value()/MAX_HEXAGON_THREATS, 1)
+}
+
+// Captured tiles can only be used by their owner, and a super hexagon clears
+// seven of them. Neighborhoods one or two captures away from a super hexagon
+// are good for the side if they hold more of the opponent's captured tiles
+// than of its own. 0.5 is neutral.
+func superHexagonPotential(e *Evaluation) float64 {
+	board, side := e.Board, e.Side
+	balance := 0
+	for _, center := range board.nodesFlat() {
+		neighbors := board.GetNeighbors(center)
+		if len(neighbors) != 6 {
+			continue
+		}
+		own, opponent := 0, 0
+		for _, node := range append([]*BoardNode{center}, neighbors...) {
+			if node.Color != VeryRed && node.Color != VeryBlue {
+				continue
+			}
+			if side.IsMatching(node.Color) {
+				own++
+			} else {
+				opponent++
+			}
+		}
+		if own+opponent >= 5 && own+opponent < 7 {
+			balance += opponent - own
+		}
+	}
+	value := 0.5 + 0.5*float64(balance)/float64(7*numHexagonCenters(board))
+	return max(0, min(value, 1))
+}
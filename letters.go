@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+)
+
+// LetterDistribution is the probability of each letter being dealt into a cleared tile.
+type LetterDistribution [ALPHABET_SIZE]float64
+
+// Distribution of the letters that refill cleared tiles, used by the search.
+// Set it with the -letters flag.
+var RefillDistribution = UniformLetterDistribution()
+
+func UniformLetterDistribution() *LetterDistribution {
+	distribution := &LetterDistribution{}
+	for idx := range distribution {
+		distribution[idx] = 1.0 / ALPHABET_SIZE
+	}
+	return distribution
+}
+
+func (d *LetterDistribution) Probability(letter byte) float64 {
+	return d[letter-'A']
+}
+
+// EstimateLetterDistribution counts the letters on the boards. Every letter is
+// counted once more than it was seen, so that none of them is impossible.
+func EstimateLetterDistribution(boards []*Board) *LetterDistribution {
+	counts := [ALPHABET_SIZE]float64{}
+	for idx := range counts {
+		counts[idx] = 1
+	}
+	total := float64(ALPHABET_SIZE)
+	for _, board := range boards {
+		for _, node := range board.nodesFlat() {
+			counts[node.Letter-'A']++
+			total++
+		}
+	}
+
+	distribution := &LetterDistribution{}
+	for idx, count := range counts {
+		distribution[idx] = count / total
+	}
+	return distribution
+}
+
+// The distribution is stored as an object of letters to probabilities.
+func (d *LetterDistribution) MarshalJSON() ([]byte, error) {
+	probabilities := make(map[string]float64, ALPHABET_SIZE)
+	for idx, probability := range d {
+		probabilities[string(lettersArray[idx])] = probability
+	}
+	return json.Marshal(probabilities)
+}
+
+func (d *LetterDistribution) UnmarshalJSON(data []byte) error {
+	probabilities := map[string]float64{}
+	if err := json.Unmarshal(data, &probabilities); err != nil {
+		return err
+	}
+
+	total := 0.0
+	counts := [ALPHABET_SIZE]float64{}
+	for letter, probability := range probabilities {
+		if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+			return fmt.Errorf("invalid letter %q in letter distribution", letter)
+		}
+		if probability < 0 {
+			return fmt.Errorf("letter %s has negative probability %f", letter, probability)
+		}
+		counts[letter[0]-'A'] = probability
+		total += probability
+	}
+	if total == 0 {
+		return fmt.Errorf("letter distribution is empty")
+	}
+
+	// Normalize, so that counts can be stored as well as probabilities.
+	for idx, count := range counts {
+		d[idx] = count / total
+	}
+	return nil
+}
+
+func LoadLetterDistribution(path string) (*LetterDistribution, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	distribution := &LetterDistribution{}
+	if err := json.Unmarshal(data, distribution); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return distribution, nil
+}
+
+func (d *LetterDistribution) Save(path string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Sample draws a letter from the distribution.
+func (d *LetterDistribution) Sample(rng *rand.Rand) byte {
+	x := rng.Float64()
+	for idx, probability := range d {
+		x -= probability
+		if x < 0 {
+			return lettersArray[idx]
+		}
+	}
+	return lettersArray[ALPHABET_SIZE-1]
+}
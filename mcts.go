@@ -0,0 +1,231 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Iterations of the MCTS search when neither the time nor the iterations are limited.
+const MCTS_ITERATIONS = 200
+
+// Default weight of the exploration term of UCT.
+const MCTS_EXPLORATION = 1.4
+
+// Moves played by a playout before it is scored with the evaluator instead
+// of playing the game out.
+const MAX_PLAYOUT_MOVES = 40
+
+// How playouts choose their moves.
+const (
+	RandomPlayout = "random"
+	// Play the word that colors the most grey tiles, the first word FindWords returns.
+	GreedyPlayout = "greedy"
+)
+
+// A node of the MCTS tree: the position after the moves from the root to it.
+type mctsNode struct {
+	parent *mctsNode
+	// The move leading to the node, nil at the root.
+	word *Word
+	// Side that plays the moves to the children.
+	mover    Mover
+	children []*mctsNode
+	// Moves that don't have a child yet, nil until the node is expanded.
+	untried  []*Word
+	expanded bool
+	visits   int
+	// Sum of the outcomes of the playouts through the node, 1 for a win of
+	// the side the search is for and 0 for a loss.
+	total float64
+}
+
+// Value of the node for the side that moved into it.
+func (n *mctsNode) value(side Mover) float64 {
+	mean := n.total / float64(n.visits)
+	if n.parent.mover != side {
+		return 1 - mean
+	}
+	return mean
+}
+
+// Picks the child with the best UCT score.
+func (n *mctsNode) selectChild(exploration float64, side Mover) *mctsNode {
+	var best *mctsNode
+	bestScore := math.Inf(-1)
+	logVisits := math.Log(float64(n.visits))
+	for _, child := range n.children {
+		score := child.value(side) + exploration*math.Sqrt(logVisits/float64(child.visits))
+		if score > bestScore {
+			best = child
+			bestScore = score
+		}
+	}
+	return best
+}
+
+type mctsSearch struct {
+	// Side the search is for.
+	side    Mover
+	trie    *Trie
+	options SearchOptions
+	// Scores the playouts that don't finish.
+	evaluator Evaluator
+	rng       *rand.Rand
+	// Depth is the deepest node of the tree, and the nodes are the ones
+	// iterations went through.
+	stats *SearchStats
+}
+
+// ExecuteMCTS picks a move with Monte Carlo tree search, using the UCT rule
+// to balance searching promising moves and rarely tried ones. Every
+// iteration plays a game out from a leaf of the tree, dealing random letters
+// into cleared tiles, so the search sees the luck of the refills that a
+// depth-limited search can't.
+//
+// Tiles cleared by the moves in the tree stay cleared until the playout
+// deals them, so the tree only holds moves that don't depend on refills.
+// The search runs for options.Time, or options.Iterations iterations. The
+// table isn't used. It returns nil if the mover has no moves.
+func ExecuteMCTS(board *Board, trie *Trie, options SearchOptions, table *TranspositionTable) *Move {
+	start := time.Now()
+	s := &mctsSearch{side: board.SideToMove(), trie: trie, options: options, evaluator: options.evaluator(trie), rng: rand.New(rand.NewSource(options.Seed)), stats: &SearchStats{}}
+	if s.options.Exploration == 0 {
+		s.options.Exploration = MCTS_EXPLORATION
+	}
+	iterations := options.Iterations
+	if iterations == 0 && options.Time == 0 {
+		iterations = MCTS_ITERATIONS
+	}
+	deadline := time.Now().Add(options.Time)
+
+	root := &mctsNode{mover: s.side}
+	for idx := 0; ; idx++ {
+		if iterations > 0 && idx >= iterations {
+			break
+		}
+		// The first iteration always runs, so that the root is expanded.
+		if options.Time > 0 && idx > 0 && time.Now().After(deadline) {
+			break
+		}
+		s.iterate(root, board.clone())
+	}
+	if options.Stats != nil {
+		s.stats.Elapsed = time.Since(start)
+		options.Stats.Add(s.stats)
+	}
+
+	var best *mctsNode
+	for _, child := range root.children {
+		if best == nil || child.visits > best.visits {
+			best = child
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &Move{word: best.word, Mover: root.mover}
+}
+
+// Runs one iteration of the search on a copy of the root board: selects a
+// leaf, expands it, plays a game out from it and backs up the outcome.
+func (s *mctsSearch) iterate(root *mctsNode, board *Board) {
+	node := root
+	ply := 0
+	s.stats.visit(ply)
+	for node.expanded && len(node.untried) == 0 && len(node.children) > 0 {
+		node = node.selectChild(s.options.Exploration, s.side)
+		ply++
+		s.stats.visit(ply)
+		if _, err := board.MakeMove(node.word, node.parent.mover); err != nil {
+			panic(err)
+		}
+	}
+
+	if board.GetTerminalResult(s.side) == -1 {
+		if !node.expanded {
+			node.expanded = true
+			words := FindWords(board, s.trie, node.mover)
+			s.stats.generated(words)
+			s.stats.ExpandedNodes++
+			for _, word := range words {
+				if word.Probability >= 1 {
+					node.untried = append(node.untried, word)
+				}
+			}
+		}
+		if len(node.untried) > 0 {
+			idx := s.rng.Intn(len(node.untried))
+			word := node.untried[idx]
+			node.untried[idx] = node.untried[len(node.untried)-1]
+			node.untried = node.untried[:len(node.untried)-1]
+			if _, err := board.MakeMove(word, node.mover); err == nil {
+				child := &mctsNode{parent: node, word: word, mover: node.mover.Opposite()}
+				node.children = append(node.children, child)
+				node = child
+				ply++
+				s.stats.visit(ply)
+				s.stats.SearchedMoves++
+				if ply > s.stats.Depth {
+					s.stats.Depth = ply
+				}
+			}
+		}
+	}
+
+	outcome := s.playout(board, node.mover)
+	for ; node != nil; node = node.parent {
+		node.visits++
+		node.total += outcome
+	}
+}
+
+// Plays the game out with mover to move, dealing letters into the cleared
+// tiles after every move, and returns 1 if the side of the search wins and 0 if it loses. Games
+// that don't finish within MAX_PLAYOUT_MOVES are scored with the evaluator.
+func (s *mctsSearch) playout(board *Board, mover Mover) float64 {
+	passes := 0
+	for moveNum := 0; moveNum < MAX_PLAYOUT_MOVES; moveNum++ {
+		if result := board.GetTerminalResult(s.side); result != -1 {
+			return result
+		}
+		s.refill(board)
+
+		words := FindWords(board, s.trie, mover)
+		s.stats.generated(words)
+		if len(words) == 0 {
+			// Neither side can move.
+			passes++
+			if passes == 2 {
+				break
+			}
+			mover = mover.Opposite()
+			continue
+		}
+		passes = 0
+
+		word := words[0]
+		if s.options.Playout != GreedyPlayout {
+			word = words[s.rng.Intn(len(words))]
+		}
+		if _, err := board.MakeMove(word, mover); err != nil {
+			panic(err)
+		}
+		mover = mover.Opposite()
+	}
+	if result := board.GetTerminalResult(s.side); result != -1 {
+		return result
+	}
+	return s.evaluator.Evaluate(board, s.side)
+}
+
+// Deals a random letter into every cleared tile.
+func (s *mctsSearch) refill(board *Board) {
+	for _, node := range board.nodesFlat() {
+		if node.cleared {
+			if err := board.Refill(node.coords, RefillDistribution.Sample(s.rng)); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
+// Depth searched when there is no time limit.
 const DEPTH = 2
 
+// Deepest iteration searched when only the time is limited.
+const MAX_DEPTH = 32
+
+// Most words through cleared tiles searched at a node. There are often
+// thousands of them, each needing a search of its own, so only the most likely
+// ones are considered.
+const MAX_CHANCE_WORDS = 64
+
 type Mover string
 
 const (
@@ -45,13 +57,107 @@ func (m Mover) IsMatchingDrab(c Color) bool {
 	return c == Blue
 }
 
-// Execute minimax algorithm on the board
-func ExecuteMinimax(board *Board, trie *Trie) *Move {
-	bestResult := runMinimax(trie, board, BlueMover, 0.0, 1.0, DEPTH, []*Move{}, 1)
+func (o SearchOptions) maxDepth() int {
+	if o.Depth > 0 {
+		return o.Depth
+	}
+	if o.Time > 0 {
+		return MAX_DEPTH
+	}
+	return DEPTH
+}
 
-	// fmt.Println("Best result:", bestResult.String())
+// State shared by the nodes of a search.
+type searcher struct {
+	// Side the search is for. Scores are its chances of winning, which it
+	// maximizes and the opponent minimizes.
+	side      Mover
+	trie      *Trie
+	evaluator Evaluator
+	table     *TranspositionTable
+	stats     *SearchStats
+	deadline  time.Time
+	// Set once the deadline has passed, to unwind the iteration in progress.
+	aborted bool
+}
 
-	return bestResult.moves[0]
+// Key of the position in the transposition table. Scores are from the side
+// of the search, so searches for either side can share the table.
+func (s *searcher) key(board *Board, mover Mover) uint64 {
+	key := board.Hash(mover)
+	if s.side == RedMover {
+		key ^= zobristKey(zobristRedSide, 0, 0)
+	}
+	return key
+}
+
+// Reports whether the search has run out of time.
+func (s *searcher) stopped() bool {
+	if !s.aborted && !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.aborted = true
+	}
+	return s.aborted
+}
+
+// Execute minimax algorithm on the board, deepening the search one move at a
+// time until the limits are reached. The best move of the deepest completed
+// iteration is returned, or nil if the mover has no moves. The table may be
+// nil, and may be kept between searches.
+func ExecuteMinimax(board *Board, trie *Trie, options SearchOptions, table *TranspositionTable) *Move {
+	lines := ExecuteMultiPV(board, trie, options, table)
+	if len(lines) == 0 || len(lines[0].moves) == 0 {
+		return nil
+	}
+	return lines[0].moves[0]
+}
+
+// ExecuteMultiPV searches like ExecuteMinimax and returns the principal
+// variations of the best options.MultiPV moves, best first, or of the best
+// move if MultiPV is zero.
+func ExecuteMultiPV(board *Board, trie *Trie, options SearchOptions, table *TranspositionTable) []*MinimaxResult {
+	start := time.Now()
+	evaluator := options.evaluator(trie)
+	pool := newRootPool(board, trie, evaluator, table, options.Threads)
+	defer pool.close()
+	// The root shares the first shard of the table with the first worker, which is idle while the root uses it.
+	s := &searcher{side: board.SideToMove(), trie: trie, evaluator: evaluator, table: pool.workers[0].searcher.table, stats: &SearchStats{}}
+	multiPV := options.MultiPV
+	if multiPV < 1 {
+		multiPV = 1
+	}
+
+	var deadline time.Time
+	if options.Time > 0 {
+		deadline = start.Add(options.Time)
+	}
+	var bestLines []*MinimaxResult
+	for depth := 1; depth <= options.maxDepth(); depth++ {
+		lines := s.searchRoot(board, pool, depth, multiPV)
+		if s.aborted {
+			break
+		}
+		bestLines = lines
+		s.stats.Depth = depth
+
+		// The first iteration always completes, so that there is a move to
+		// play, but its time counts towards the budget of the next ones.
+		s.deadline = deadline
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+	}
+
+	// fmt.Println("Best result:", bestLines[0].String())
+
+	if options.Stats != nil {
+		options.Stats.Add(s.stats)
+		for _, worker := range pool.workers {
+			options.Stats.Add(worker.searcher.stats)
+		}
+		options.Stats.Elapsed += time.Since(start)
+	}
+
+	return bestLines
 }
 
 type MinimaxResult struct {
@@ -61,52 +167,422 @@ type MinimaxResult struct {
 	probability float64
 }
 
-func runMinimax(trie *Trie, board *Board, mover Mover, alpha float64, beta float64, depth int, moves []*Move, probability float64) *MinimaxResult {
+// Searches the root of the tree like runMinimax, with its moves split
+// between the workers of the pool. It returns the multiPV best results, best
+// first. The window is kept open below the worst of them, so their scores are
+// exact rather than bounds.
+func (s *searcher) searchRoot(board *Board, pool *rootPool, depth int, multiPV int) []*MinimaxResult {
+	mover := s.side
+	moves := []*Move{}
+	s.stats.visit(0)
+	terminalResult := board.GetTerminalResult(s.side)
+	if terminalResult != -1 {
+		return []*MinimaxResult{{score: float64(terminalResult), moves: moves, probability: 1}}
+	}
+
+	key := s.key(board, mover)
+	var tableMove *Word
+	if s.table != nil {
+		if entry, ok := s.table.Probe(key); ok {
+			// The table only has the best move, so it can't answer for more.
+			if score, ok := entry.Score(depth, 0, 1); ok && multiPV == 1 {
+				s.table.stats.cutoffs++
+				return []*MinimaxResult{{score: score, moves: appendMove(moves, &Move{word: entry.move, Mover: mover}), probability: 1}}
+			}
+			tableMove = entry.move
+		}
+	}
+
+	// The move at the root has to be played now, so it can't go through cleared tiles.
+	words := []*Word{}
+	allWords := FindWords(board, s.trie, mover)
+	s.stats.generated(allWords)
+	for _, word := range allWords {
+		if word.Probability >= 1 {
+			words = append(words, word)
+		}
+	}
+	if tableMove != nil {
+		moveToFront(words, tableMove)
+	}
+
+	alpha, beta := 0.0, 1.0
+	// The best results so far, best first.
+	lines := []*MinimaxResult{}
+	// A failed search is only returned if no move has a score, as runMinimax does.
+	var failed *MinimaxResult
+	if len(words) > 0 {
+		s.stats.ExpandedNodes++
+	}
+	for start, end := 0, 0; start < len(words); start = end {
+		// Until there are multiPV lines, there is no bound to search with, so
+		// only as many moves as are missing are searched at once: with a
+		// single line, the first move is searched alone and the others get
+		// its bound.
+		batch := pool.size()
+		if missing := multiPV - len(lines); missing > 0 && missing < batch {
+			batch = missing
+		}
+		end = start + batch
+		if end > len(words) {
+			end = len(words)
+		}
+		results := pool.search(words[start:end], mover, alpha, beta, depth-1, s.deadline)
+		if pool.aborted() {
+			s.aborted = true
+			return []*MinimaxResult{{score: -1, moves: moves, probability: 1}}
+		}
+		// The results are merged in the order of the words, as runMinimax would.
+		for idx, result := range results {
+			if result == nil {
+				continue
+			}
+			s.stats.SearchedMoves++
+			if result.score == -1 {
+				if failed == nil {
+					failed = result
+				}
+				continue
+			}
+			lines = insertLine(lines, result, multiPV)
+			if len(lines) == multiPV {
+				if lines[multiPV-1].score >= beta {
+					s.stats.cutoff(start + idx)
+					s.store(key, depth, LowerBound, lines[0], 0)
+					return lines
+				}
+				alpha = max(alpha, lines[multiPV-1].score)
+			}
+		}
+	}
+	if len(lines) == 0 {
+		if failed != nil {
+			return []*MinimaxResult{failed}
+		}
+		return []*MinimaxResult{{score: -1, moves: moves, probability: 1}}
+	}
+	s.store(key, depth, ExactBound, lines[0], 0)
+	return lines
+}
+
+// Inserts the result into lines, sorted best first, keeping at most n. A
+// result doesn't go ahead of results with the same score. The same word is
+// often found along different paths or after different swaps, so a line
+// only replaces the line with its word if it scores better.
+func insertLine(lines []*MinimaxResult, result *MinimaxResult, n int) []*MinimaxResult {
+	word := result.moves[0].word.String()
+	for idx, line := range lines {
+		if line.moves[0].word.String() == word {
+			if result.score <= line.score {
+				return lines
+			}
+			lines = append(lines[:idx], lines[idx+1:]...)
+			break
+		}
+	}
+	idx := len(lines)
+	for idx > 0 && result.score > lines[idx-1].score {
+		idx--
+	}
+	if idx >= n {
+		return lines
+	}
+	lines = append(lines, nil)
+	copy(lines[idx+1:], lines[idx:])
+	lines[idx] = result
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return lines
+}
+
+func (s *searcher) runMinimax(board *Board, mover Mover, alpha float64, beta float64, depth int, moves []*Move, probability float64) *MinimaxResult {
+	if s.stopped() {
+		return &MinimaxResult{score: -1, moves: moves, probability: probability}
+	}
+	s.stats.visit(len(moves))
 	if probability <= 0.01 {
+		s.stats.ProbabilityPrunes++
 		return &MinimaxResult{score: -1, moves: moves, probability: probability}
 	}
-	terminalResult := board.GetTerminalResult()
+	terminalResult := board.GetTerminalResult(s.side)
 	if terminalResult != -1 {
-		return &MinimaxResult{score: float64(terminalResult) * probability, moves: moves, probability: probability}
+		return &MinimaxResult{score: float64(terminalResult), moves: moves, probability: probability}
 	}
 	if depth == 0 {
-		return &MinimaxResult{score: board.heuristic() * probability, moves: moves, probability: probability}
+		return &MinimaxResult{score: s.evaluator.Evaluate(board, s.side), moves: moves, probability: probability}
+	}
+
+	key := s.key(board, mover)
+	var tableMove *Word
+	if s.table != nil {
+		if entry, ok := s.table.Probe(key); ok {
+			if score, ok := entry.Score(depth, alpha, beta); ok {
+				s.table.stats.cutoffs++
+				return &MinimaxResult{score: score, moves: appendMove(moves, &Move{word: entry.move, Mover: mover}), probability: probability}
+			}
+			tableMove = entry.move
+		}
 	}
 
-	words := FindWords(board, trie, mover)
+	words := FindWords(board, s.trie, mover)
+	s.stats.generated(words)
 	if len(words) == 0 {
 		return &MinimaxResult{score: -1, moves: moves, probability: probability}
 	}
 
-	if mover == BlueMover {
-		var best *MinimaxResult
-		for _, word := range words {
-			result := runMinimax(trie, word.board, RedMover, alpha, beta, depth-1, append(moves, &Move{word: word, Mover: mover}), probability*word.Probability)
+	// Words through cleared tiles depend on the letters that refill them. The
+	// move at the root has to be played now, so it can't rely on them.
+	certainWords := make([]*Word, 0, len(words))
+	chanceWords := []*Word{}
+	for _, word := range words {
+		if word.Probability >= 1 {
+			certainWords = append(certainWords, word)
+		} else if len(moves) > 0 {
+			chanceWords = append(chanceWords, word)
+		}
+	}
+	if len(chanceWords) > MAX_CHANCE_WORDS {
+		sort.SliceStable(chanceWords, func(i, j int) bool {
+			return chanceWords[i].Probability > chanceWords[j].Probability
+		})
+		s.stats.ChanceWordsDropped += int64(len(chanceWords) - MAX_CHANCE_WORDS)
+		chanceWords = chanceWords[:MAX_CHANCE_WORDS]
+	}
+	if tableMove != nil {
+		moveToFront(certainWords, tableMove)
+	}
+	alphaOrig, betaOrig := alpha, beta
+	if len(chanceWords) > 0 {
+		// The chance words are weighed against the best certain word, so its
+		// score has to be exact rather than a bound.
+		if mover == s.side {
+			alpha = 0
+		} else {
+			beta = 1
+		}
+	}
+
+	s.stats.ExpandedNodes++
+	var best *MinimaxResult
+	if mover == s.side {
+		for idx, word := range certainWords {
+			delta, err := board.MakeMove(word, mover)
+			if err != nil {
+				continue
+			}
+			s.stats.SearchedMoves++
+			result := s.runMinimax(board, mover.Opposite(), alpha, beta, depth-1, appendMove(moves, &Move{word: word, Mover: mover}), probability*word.Probability)
+			board.UnmakeMove(delta)
 			if best == nil || (result.score > best.score && result.score != -1) {
 				best = result
 			}
 			if best.score >= beta {
-				break
+				// Chance words can only improve on this, so the cutoff still holds.
+				s.stats.cutoff(idx)
+				s.store(key, depth, LowerBound, best, len(moves))
+				return best
 			}
 			alpha = max(alpha, best.score)
 		}
-		return best
-	} else if mover == RedMover {
-		var best *MinimaxResult
-		for _, word := range words {
-			result := runMinimax(trie, word.board, BlueMover, alpha, beta, depth-1, append(moves, &Move{word: word, Mover: mover}), probability*word.Probability)
+	} else if mover == s.side.Opposite() {
+		for idx, word := range certainWords {
+			delta, err := board.MakeMove(word, mover)
+			if err != nil {
+				continue
+			}
+			s.stats.SearchedMoves++
+			result := s.runMinimax(board, mover.Opposite(), alpha, beta, depth-1, appendMove(moves, &Move{word: word, Mover: mover}), probability*word.Probability)
+			board.UnmakeMove(delta)
 			if best == nil || (result.score < best.score && result.score != -1) {
 				best = result
 			}
 			if best.score <= alpha {
-				break
+				s.stats.cutoff(idx)
+				s.store(key, depth, UpperBound, best, len(moves))
+				return best
 			}
 			beta = min(beta, best.score)
 		}
-		return best
 	} else {
 		panic("Invalid mover")
 	}
+	if (best == nil || best.score == -1) && len(chanceWords) > 0 && !s.stopped() {
+		// Without a certain word, the chance words are weighed against not
+		// moving, which scores the position as it is.
+		best = &MinimaxResult{score: s.evaluator.Evaluate(board, s.side), moves: moves, probability: probability}
+	}
+	if best == nil {
+		return &MinimaxResult{score: -1, moves: moves, probability: probability}
+	}
+	if len(chanceWords) == 0 || best.score == -1 {
+		bound := ExactBound
+		if best.score <= alphaOrig {
+			bound = UpperBound
+		} else if best.score >= betaOrig {
+			bound = LowerBound
+		}
+		s.store(key, depth, bound, best, len(moves))
+		return best
+	}
+	// The window was widened for the chance words, so the expectation is exact.
+	result := s.expectChanceWords(board, mover, depth, moves, probability, best, chanceWords)
+	s.store(key, depth, ExactBound, result, len(moves))
+	return result
+}
+
+// Saves the result of a node in the transposition table, with the move played at the node.
+func (s *searcher) store(key uint64, depth int, bound Bound, result *MinimaxResult, ply int) {
+	if s.table == nil || s.aborted || result.score == -1 || len(result.moves) <= ply {
+		return
+	}
+	s.table.Store(key, depth, bound, result.score, result.moves[ply].word)
+}
+
+// Moves the word to the front of the list, keeping the order of the others.
+func moveToFront(words []*Word, word *Word) {
+	for idx, candidate := range words {
+		if candidate.Equal(word) {
+			copy(words[1:idx+1], words[:idx])
+			words[0] = candidate
+			return
+		}
+	}
+}
+
+// chanceOutcome is a word through cleared tiles that beats the best certain word.
+type chanceOutcome struct {
+	result *MinimaxResult
+	// Letters the cleared tiles have to be refilled with for the word, by cell index.
+	refills []refill
+}
+
+type refill struct {
+	index  int
+	letter byte
+}
+
+// Expected score of a position, given the score of the best certain word: if
+// the refills allow a better chance word, the mover will play it instead.
+// Words often need the same refills, so the probabilities are worked out from
+// the letters dealt to each cleared tile rather than word by word.
+func (s *searcher) expectChanceWords(board *Board, mover Mover, depth int, moves []*Move, probability float64, best *MinimaxResult, chanceWords []*Word) *MinimaxResult {
+	isBetter := func(a float64, b float64) bool {
+		if mover == s.side {
+			return a > b
+		}
+		return a < b
+	}
+
+	outcomes := []chanceOutcome{}
+	for _, word := range chanceWords {
+		refills := []refill{}
+		for _, letter := range word.letters {
+			if node := board.Nodes[letter.coords.Line][letter.coords.Col]; node.cleared {
+				refills = append(refills, refill{index: node.index, letter: letter.Letter})
+			}
+		}
+		delta, err := board.MakeMove(word, mover)
+		if err != nil {
+			continue
+		}
+		s.stats.SearchedMoves++
+		// Only scores better than the best certain word matter, so the window starts there.
+		var result *MinimaxResult
+		if mover == s.side {
+			result = s.runMinimax(board, mover.Opposite(), best.score, 1, depth-1, appendMove(moves, &Move{word: word, Mover: mover}), probability*word.Probability)
+		} else {
+			result = s.runMinimax(board, mover.Opposite(), 0, best.score, depth-1, appendMove(moves, &Move{word: word, Mover: mover}), probability*word.Probability)
+		}
+		board.UnmakeMove(delta)
+		if result.score != -1 && isBetter(result.score, best.score) {
+			sort.Slice(refills, func(i, j int) bool { return refills[i].index < refills[j].index })
+			outcomes = append(outcomes, chanceOutcome{result: result, refills: refills})
+		}
+	}
+
+	sort.SliceStable(outcomes, func(i, j int) bool {
+		return isBetter(outcomes[i].result.score, outcomes[j].result.score)
+	})
+	// Of the words needing the same refills, only the best can be played.
+	merged := outcomes[:0]
+	seen := map[string]bool{}
+	for _, outcome := range outcomes {
+		key := fmt.Sprint(outcome.refills)
+		if !seen[key] {
+			seen[key] = true
+			merged = append(merged, outcome)
+		}
+	}
+	score := expectRefills(merged, map[int]bool{}, best.score)
+
+	return &MinimaxResult{score: score, moves: best.moves, probability: best.probability}
+}
+
+// Expected score of playing the first of the outcomes, sorted best first, whose
+// refills are dealt, or baseline if there is none. The outcomes have to agree
+// with the letters already dealt to the tiles in dealt.
+//
+// Each cleared tile is refilled independently, so this deals the tiles the
+// first outcome needs one at a time: either with one of the letters the
+// outcomes need there, or with some other letter, which rules out every
+// outcome that needs the tile.
+func expectRefills(outcomes []chanceOutcome, dealt map[int]bool, baseline float64) float64 {
+	if len(outcomes) == 0 {
+		return baseline
+	}
+	for _, next := range outcomes[0].refills {
+		if dealt[next.index] {
+			continue
+		}
+		letters := []byte{}
+		for _, outcome := range outcomes {
+			for _, refill := range outcome.refills {
+				if refill.index == next.index && bytes.IndexByte(letters, refill.letter) < 0 {
+					letters = append(letters, refill.letter)
+				}
+			}
+		}
+
+		dealt[next.index] = true
+		score := 0.0
+		other := 1.0
+		for _, letter := range letters {
+			letterProbability := RefillDistribution.Probability(letter)
+			other -= letterProbability
+			score += letterProbability * expectRefills(outcomesWithRefill(outcomes, next.index, letter), dealt, baseline)
+		}
+		score += max(other, 0) * expectRefills(outcomesWithRefill(outcomes, next.index, 0), dealt, baseline)
+		delete(dealt, next.index)
+		return score
+	}
+	// Every tile the first outcome needs has its letter.
+	return outcomes[0].result.score
+}
+
+// The outcomes that can still be played once the tile is refilled with the
+// letter, or with a letter none of them need if it is 0.
+func outcomesWithRefill(outcomes []chanceOutcome, index int, letter byte) []chanceOutcome {
+	result := []chanceOutcome{}
+	for _, outcome := range outcomes {
+		matches := true
+		for _, refill := range outcome.refills {
+			if refill.index == index && refill.letter != letter {
+				matches = false
+			}
+		}
+		if matches {
+			result = append(result, outcome)
+		}
+	}
+	return result
+}
+
+// appendMove copies the moves, since results down different branches of the search share the same prefix.
+func appendMove(moves []*Move, move *Move) []*Move {
+	result := make([]*Move, len(moves), len(moves)+1)
+	copy(result, moves)
+	return append(result, move)
 }
 
 func (r *MinimaxResult) String() string {
@@ -120,6 +596,25 @@ func (r *MinimaxResult) String() string {
 	return builder.String()
 }
 
+// Variation draws each move of the line on the board it is played on, after
+// the moves before it.
+func (r *MinimaxResult) Variation(board *Board) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Score: %f\n", r.score)
+	board = board.clone()
+	for idx, move := range r.moves {
+		fmt.Fprintf(&builder, "%d. %s\n", idx+1, move.Notation())
+		builder.WriteString(move.String(board))
+		builder.WriteString("\n")
+		if _, err := board.MakeMove(move.word, move.Mover); err != nil {
+			// The rest of the line can't be drawn.
+			fmt.Fprintf(&builder, "%s\n", err)
+			break
+		}
+	}
+	return builder.String()
+}
+
 func max(a float64, b float64) float64 {
 	if a > b {
 		return a
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Compares the expected score of chance outcomes with dealing every
+// combination of letters to the cleared tiles.
+func TestExpectRefills(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const tiles = 3
+	for round := 0; round < 200; round++ {
+		// Like the search, only keep outcomes better than the baseline.
+		baseline := rng.Float64() / 2
+		outcomes := []chanceOutcome{}
+		for count := rng.Intn(8); count > 0; count-- {
+			refills := []refill{}
+			for index := 0; index < tiles; index++ {
+				if rng.Intn(2) == 0 {
+					refills = append(refills, refill{index: index, letter: byte('A' + rng.Intn(3))})
+				}
+			}
+			if len(refills) == 0 {
+				continue
+			}
+			outcomes = append(outcomes, chanceOutcome{result: &MinimaxResult{score: 0.5 + rng.Float64()/2}, refills: refills})
+		}
+		sort.SliceStable(outcomes, func(i, j int) bool {
+			return outcomes[i].result.score > outcomes[j].result.score
+		})
+
+		expected := 0.0
+		letters := make([]byte, tiles)
+		var deal func(index int, probability float64)
+		deal = func(index int, probability float64) {
+			if index == tiles {
+				score := baseline
+				for _, outcome := range outcomes {
+					if outcomeIsDealt(outcome, letters) {
+						score = max(score, outcome.result.score)
+					}
+				}
+				expected += probability * score
+				return
+			}
+			for letter := byte('A'); letter <= 'Z'; letter++ {
+				letters[index] = letter
+				deal(index+1, probability*RefillDistribution.Probability(letter))
+			}
+		}
+		deal(0, 1)
+
+		if score := expectRefills(outcomes, map[int]bool{}, baseline); math.Abs(score-expected) > 1e-9 {
+			t.Fatalf("round %d: expected score %f, got %f", round, expected, score)
+		}
+	}
+}
+
+func outcomeIsDealt(outcome chanceOutcome, letters []byte) bool {
+	for _, refill := range outcome.refills {
+		if letters[refill.index] != refill.letter {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInsertLineKeepsBestOfSameWord(t *testing.T) {
+	line := func(text string, score float64) *MinimaxResult {
+		word := &Word{Probability: 1}
+		for idx := 0; idx < len(text); idx++ {
+			word.letters = append(word.letters, &WordLetter{coords: Coords{Line: idx}, Letter: text[idx]})
+		}
+		return &MinimaxResult{score: score, moves: []*Move{{word: word, Mover: BlueMover}}}
+	}
+	lines := []*MinimaxResult{}
+	for _, result := range []*MinimaxResult{
+		line("CAT", 0.5), line("CAT", 0.4), line("DOG", 0.3), line("DOG", 0.6), line("COT", 0.2), line("CAT", 0.7),
+	} {
+		lines = insertLine(lines, result, 3)
+	}
+	expected := []string{"CAT 0.70", "DOG 0.60", "COT 0.20"}
+	if len(lines) != len(expected) {
+		t.Fatalf("got %d lines, expected %d", len(lines), len(expected))
+	}
+	for idx, line := range lines {
+		if got := fmt.Sprintf("%s %.2f", line.moves[0].word, line.score); got != expected[idx] {
+			t.Errorf("line %d is %s, expected %s", idx+1, got, expected[idx])
+		}
+	}
+}
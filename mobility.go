@@ -0,0 +1,100 @@
+package main
+
+// Words counted by the mobility features, and tiles visited looking for them.
+// Counting stops at either limit, which keeps the features cheap enough to
+// compute at every leaf of the search, also when a side has few words and
+// would otherwise have the whole board searched.
+const (
+	MOBILITY_LIMIT      = 64
+	MOBILITY_NODE_LIMIT = 1024
+)
+
+// wordCounter counts words like FindWords finds them, up to the limits.
+type wordCounter struct {
+	*wordSearch
+	count     int
+	limit     int
+	nodes     int
+	nodeLimit int
+}
+
+func (c *wordCounter) done() bool {
+	return c.count >= c.limit || c.nodes >= c.nodeLimit
+}
+
+// CountWords counts the words the mover can play, up to limit, visiting at
+// most nodeLimit tiles. Words that need a swap or a refill aren't counted, so
+// this is an estimate of how many moves the mover has rather than the number
+// FindWords would return. If the tiles run out first, the count is
+// extrapolated from the starting tiles that were searched.
+func CountWords(board *Board, trie *Trie, mover Mover, limit int, nodeLimit int) int {
+	c := &wordCounter{wordSearch: newWordSearch(board, trie, mover), limit: limit, nodeLimit: nodeLimit}
+	nodes := board.nodesFlat()
+	for idx, node := range nodes {
+		if c.count >= limit {
+			return limit
+		}
+		if c.nodes >= nodeLimit {
+			if idx == 0 {
+				return 0
+			}
+			// The last starting tile may not have been searched completely.
+			estimate := c.count * len(nodes) / idx
+			if estimate > limit {
+				return limit
+			}
+			return estimate
+		}
+		c.countRecursive(node)
+	}
+	if c.count > limit {
+		return limit
+	}
+	return c.count
+}
+
+func (c *wordCounter) countRecursive(node *BoardNode) {
+	tile := &c.tiles[node.index]
+	if tile.used || tile.cleared || !c.mover.IsMatching(tile.color) {
+		return
+	}
+
+	c.nodes++
+	c.accumulation = append(c.accumulation, &AccumulatedNode{Letter: tile.letter, coords: node.coords, Color: tile.color})
+	tile.used = true
+	wordFindResult := c.trie.Find(c.accumulation)
+	if wordFindResult.IsWord {
+		c.count++
+	}
+	if wordFindResult.IsPrefix {
+		for _, neighbor := range c.board.GetNeighbors(node) {
+			if c.done() {
+				break
+			}
+			c.countRecursive(neighbor)
+		}
+	}
+	tile.used = false
+	c.accumulation = c.accumulation[:len(c.accumulation)-1]
+}
+
+// How many words the side can play, up to MOBILITY_LIMIT. Without a
+// dictionary it is neutral.
+func ownMobility(trie *Trie) func(e *Evaluation) float64 {
+	if trie == nil {
+		return func(e *Evaluation) float64 { return 0.5 }
+	}
+	return func(e *Evaluation) float64 {
+		return float64(CountWords(e.Board, trie, e.Side, MOBILITY_LIMIT, MOBILITY_NODE_LIMIT)) / MOBILITY_LIMIT
+	}
+}
+
+// How few words the opponent can play.
+func opponentMobility(trie *Trie) func(e *Evaluation) float64 {
+	if trie == nil {
+		return func(e *Evaluation) float64 { return 0.5 }
+	}
+	return func(e *Evaluation) float64 {
+		return 1 - float64(CountWords(e.Board, trie, e.Side.Opposite(), MOBILITY_LIMIT, MOBILITY_NODE_LIMIT))/MOBILITY_LIMIT
+	}
+}
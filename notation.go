@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Text notation for moves and games, compact enough to paste in a chat.
+//
+// A cell is written as its line and column, as in the board JSON: "8,2".
+//
+// A move is the side that played it (R or B), the swap made before the word
+// if there was one (two cells joined by "<>"), the path (cells joined by "-")
+// and the word:
+//
+//	B 8,0-9,0-10,1 CAT
+//	R 9,1<>10,1 8,0-9,0-10,1 COT
+//
+// A board is written line by line, separated by "/". Each cell is its letter,
+// followed by "r" or "b" if it is red or blue and "rr" or "bb" if it is very
+// red or very blue:
+//
+//	D/LB/UrCA/AVrBL/...
+//
+// If the board says which side is to move, the side follows after a space:
+//
+//	D/LB/UrCA/AVrBL/... R
+//
+// A game starts with headers in square brackets, then has one move per line,
+// numbered from 1. Each move is followed by the score after it, blue first,
+// and then by the letters that refilled the cleared tiles, if any:
+//
+//	[Board "D/LB/UrCA/..."]
+//	[Score "B3 R2"]
+//	[Blue "jeremy"]
+//	1. B 8,0-9,0-10,1 CAT {B4 R2} +9,0=E +10,1=A
+//	2. R 4,2-5,2-6,3 DOG {B4 R2}
+//
+// The Board header is required, Score defaults to "B0 R0" and any other
+// header is kept as is.
+
+func formatCoords(coords Coords) string {
+	return fmt.Sprintf("%d,%d", coords.Line, coords.Col)
+}
+
+func parseCoords(s string) (Coords, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Coords{}, fmt.Errorf("invalid cell %q", s)
+	}
+	line, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Coords{}, fmt.Errorf("invalid cell %q", s)
+	}
+	col, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Coords{}, fmt.Errorf("invalid cell %q", s)
+	}
+	return Coords{Line: line, Col: col}, nil
+}
+
+func formatSide(mover Mover) string {
+	if mover == RedMover {
+		return "R"
+	}
+	return "B"
+}
+
+func parseSide(s string) (Mover, error) {
+	switch s {
+	case "R":
+		return RedMover, nil
+	case "B":
+		return BlueMover, nil
+	}
+	return "", fmt.Errorf("invalid side %q", s)
+}
+
+func formatScore(score BoardScore) string {
+	return fmt.Sprintf("B%d R%d", score.Blue, score.Red)
+}
+
+func parseScore(s string) (BoardScore, error) {
+	score := BoardScore{}
+	if _, err := fmt.Sscanf(s, "B%d R%d", &score.Blue, &score.Red); err != nil {
+		return score, fmt.Errorf("invalid score %q", s)
+	}
+	return score, nil
+}
+
+// FormatMove writes a move in text notation. The score and refills are not included.
+func FormatMove(m *GameMove) string {
+	var builder strings.Builder
+	builder.WriteString(formatSide(m.Mover))
+	if len(m.Swap) == 2 {
+		fmt.Fprintf(&builder, " %s<>%s", formatCoords(m.Swap[0]), formatCoords(m.Swap[1]))
+	}
+	builder.WriteString(" ")
+	for idx, coords := range m.Path {
+		if idx > 0 {
+			builder.WriteString("-")
+		}
+		builder.WriteString(formatCoords(coords))
+	}
+	fmt.Fprintf(&builder, " %s", m.Word)
+	return builder.String()
+}
+
+// ParseMove reads a move written by FormatMove.
+func ParseMove(s string) (*GameMove, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("invalid move %q", s)
+	}
+	mover, err := parseSide(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	move := &GameMove{Mover: mover, Word: fields[len(fields)-1]}
+
+	if len(fields) == 4 {
+		cells := strings.Split(fields[1], "<>")
+		if len(cells) != 2 {
+			return nil, fmt.Errorf("invalid swap %q", fields[1])
+		}
+		for _, cell := range cells {
+			coords, err := parseCoords(cell)
+			if err != nil {
+				return nil, err
+			}
+			move.Swap = append(move.Swap, coords)
+		}
+	}
+
+	for _, cell := range strings.Split(fields[len(fields)-2], "-") {
+		coords, err := parseCoords(cell)
+		if err != nil {
+			return nil, err
+		}
+		move.Path = append(move.Path, coords)
+	}
+
+	for idx := 0; idx < len(move.Word); idx++ {
+		if move.Word[idx] < 'A' || move.Word[idx] > 'Z' {
+			return nil, fmt.Errorf("invalid word %q", move.Word)
+		}
+	}
+	if len(move.Word) != len(move.Path) {
+		return nil, fmt.Errorf("word %s has %d letters but the path has %d cells", move.Word, len(move.Word), len(move.Path))
+	}
+	return move, nil
+}
+
+// Notation writes a move found by the search in text notation.
+func (m *Move) Notation() string {
+	move := &GameMove{Mover: m.Mover, Swap: m.word.SwappedNodes, Word: m.word.String()}
+	for _, letter := range m.word.letters {
+		move.Path = append(move.Path, letter.coords)
+	}
+	return FormatMove(move)
+}
+
+var colorSuffixes = map[Color]string{
+	None:     "",
+	Red:      "r",
+	Blue:     "b",
+	VeryRed:  "rr",
+	VeryBlue: "bb",
+}
+
+// FormatBoard writes the letters and colors of a board, and the side to move
+// if it is set. The score is not included.
+func FormatBoard(b *Board) string {
+	var builder strings.Builder
+	for lineNum, line := range b.Nodes {
+		if lineNum > 0 {
+			builder.WriteString("/")
+		}
+		for _, node := range line {
+			builder.WriteString(node.Char)
+			builder.WriteString(colorSuffixes[node.Color])
+		}
+	}
+	if b.ToMove != "" {
+		fmt.Fprintf(&builder, " %s", formatSide(b.ToMove))
+	}
+	return builder.String()
+}
+
+// ParseBoard reads a board written by FormatBoard. The board is validated and initialized.
+func ParseBoard(s string) (*Board, error) {
+	board := &Board{Nodes: [][]*BoardNode{}}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid board %q", s)
+	}
+	if len(fields) == 2 {
+		toMove, err := parseSide(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		board.ToMove = toMove
+	}
+	for _, text := range strings.Split(fields[0], "/") {
+		line := []*BoardNode{}
+		for idx := 0; idx < len(text); {
+			node := &BoardNode{Char: text[idx : idx+1], Letter: text[idx]}
+			idx++
+			suffixStart := idx
+			for idx < len(text) && text[idx] >= 'a' && text[idx] <= 'z' {
+				idx++
+			}
+			for color, suffix := range colorSuffixes {
+				if suffix == text[suffixStart:idx] {
+					node.Color = color
+				}
+			}
+			if node.Color == "" {
+				return nil, fmt.Errorf("invalid color %q in line %d of the board", text[suffixStart:idx], len(board.Nodes))
+			}
+			line = append(line, node)
+		}
+		board.Nodes = append(board.Nodes, line)
+	}
+	if err := board.Validate(); err != nil {
+		return nil, err
+	}
+	board.Initialize()
+	return board, nil
+}
+
+// Notation writes the game in text notation.
+func (g *Game) Notation() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "[Board %q]\n", FormatBoard(g.Board))
+	fmt.Fprintf(&builder, "[Score %q]\n", formatScore(g.Board.Score))
+
+	keys := make([]string, 0, len(g.Headers))
+	for key := range g.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&builder, "[%s %q]\n", key, g.Headers[key])
+	}
+
+	for idx, move := range g.Moves {
+		fmt.Fprintf(&builder, "%d. %s {%s}", idx+1, FormatMove(move), formatScore(move.Score))
+		for _, refill := range move.Refills {
+			fmt.Fprintf(&builder, " +%s=%s", formatCoords(refill.Coords), refill.Char)
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// ParseGameNotation reads a game written by Game.Notation.
+func ParseGameNotation(r io.Reader) (*Game, error) {
+	game := &Game{Moves: []*GameMove{}}
+	score := BoardScore{}
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			key, value, err := parseHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			switch key {
+			case "Board":
+				game.Board, err = ParseBoard(value)
+			case "Score":
+				score, err = parseScore(value)
+			default:
+				if game.Headers == nil {
+					game.Headers = map[string]string{}
+				}
+				game.Headers[key] = value
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			continue
+		}
+
+		move, err := parseGameMove(line, len(game.Moves)+1)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		game.Moves = append(game.Moves, move)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if game.Board == nil {
+		return nil, fmt.Errorf("game has no Board header")
+	}
+	game.Board.setScore(score)
+	return game, nil
+}
+
+func parseHeader(line string) (string, string, error) {
+	if !strings.HasSuffix(line, "]") {
+		return "", "", fmt.Errorf("invalid header %q", line)
+	}
+	parts := strings.SplitN(line[1:len(line)-1], " ", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid header %q", line)
+	}
+	value, err := strconv.Unquote(parts[1])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid header %q", line)
+	}
+	return parts[0], value, nil
+}
+
+// Parses a numbered move line of a game, with its score and optional refills.
+func parseGameMove(line string, number int) (*GameMove, error) {
+	prefix := fmt.Sprintf("%d. ", number)
+	if !strings.HasPrefix(line, prefix) {
+		return nil, fmt.Errorf("expected move %d, got %q", number, line)
+	}
+	line = line[len(prefix):]
+
+	var refills []Refill
+	fields := strings.Fields(line)
+	for len(fields) > 0 && strings.HasPrefix(fields[len(fields)-1], "+") {
+		refill := fields[len(fields)-1]
+		parts := strings.Split(refill[1:], "=")
+		if len(parts) != 2 || len(parts[1]) != 1 {
+			return nil, fmt.Errorf("invalid refill %q", refill)
+		}
+		coords, err := parseCoords(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		refills = append([]Refill{{Coords: coords, Char: parts[1]}}, refills...)
+		fields = fields[:len(fields)-1]
+	}
+	line = strings.Join(fields, " ")
+
+	var score *BoardScore
+	if open := strings.Index(line, "{"); open >= 0 {
+		if !strings.HasSuffix(line, "}") {
+			return nil, fmt.Errorf("invalid score in %q", line)
+		}
+		parsed, err := parseScore(line[open+1 : len(line)-1])
+		if err != nil {
+			return nil, err
+		}
+		score = &parsed
+		line = strings.TrimSpace(line[:open])
+	}
+
+	move, err := ParseMove(line)
+	if err != nil {
+		return nil, err
+	}
+	if score == nil {
+		return nil, fmt.Errorf("move %d has no score", number)
+	}
+	move.Score = *score
+	move.Refills = refills
+	return move, nil
+}
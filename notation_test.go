@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The side to move has to survive the board notation, the game notation and
+// the game JSON.
+func TestSideToMoveRoundTrip(t *testing.T) {
+	for _, toMove := range []Mover{"", BlueMover, RedMover} {
+		board := RandomBoard(rand.New(rand.NewSource(1)))
+		board.ToMove = toMove
+
+		parsed, err := ParseBoard(FormatBoard(board))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.ToMove != toMove || FormatBoard(parsed) != FormatBoard(board) {
+			t.Errorf("board notation: got %q to move, expected %q", parsed.ToMove, toMove)
+		}
+
+		game := NewGame(board)
+		fromNotation, err := ParseGameNotation(strings.NewReader(game.Notation()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fromNotation.Board.ToMove != toMove {
+			t.Errorf("game notation: got %q to move, expected %q", fromNotation.Board.ToMove, toMove)
+		}
+
+		data, err := json.Marshal(game)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromJSON := &Game{}
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		if fromJSON.Board.ToMove != toMove {
+			t.Errorf("game JSON: got %q to move, expected %q", fromJSON.Board.ToMove, toMove)
+		}
+	}
+}
+
+func TestMoveNotationRoundTrip(t *testing.T) {
+	for _, move := range []*GameMove{
+		{Mover: BlueMover, Path: []Coords{{8, 0}, {9, 0}, {10, 1}}, Word: "CAT"},
+		{Mover: RedMover, Swap: []Coords{{9, 1}, {10, 1}}, Path: []Coords{{8, 0}, {9, 0}, {10, 1}}, Word: "COT"},
+	} {
+		parsed, err := ParseMove(FormatMove(move))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parsed, move) {
+			t.Errorf("%s: got %+v, expected %+v", FormatMove(move), parsed, move)
+		}
+	}
+}
+
+func TestGameNotationRoundTrip(t *testing.T) {
+	board := RandomBoard(rand.New(rand.NewSource(1)))
+	board.setScore(BoardScore{Red: 2, Blue: 3})
+	game := NewGame(board)
+	game.Headers = map[string]string{"Blue": "jeremy", "Red": "a \"quoted\" name"}
+	game.Moves = []*GameMove{
+		{
+			Mover: BlueMover, Path: []Coords{{8, 0}, {9, 0}, {10, 1}}, Word: "CAT",
+			Score:   BoardScore{Red: 2, Blue: 4},
+			Refills: []Refill{{Coords: Coords{9, 0}, Char: "E"}, {Coords: Coords{10, 1}, Char: "A"}},
+		},
+		{
+			Mover: RedMover, Swap: []Coords{{4, 1}, {5, 2}}, Path: []Coords{{4, 2}, {5, 2}, {6, 3}}, Word: "DOG",
+			Score: BoardScore{Red: 2, Blue: 4},
+		},
+	}
+
+	parsed, err := ParseGameNotation(strings.NewReader(game.Notation()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if FormatBoard(parsed.Board) != FormatBoard(board) || parsed.Board.Score != board.Score {
+		t.Errorf("got board %s %+v, expected %s %+v", FormatBoard(parsed.Board), parsed.Board.Score, FormatBoard(board), board.Score)
+	}
+	if !reflect.DeepEqual(parsed.Headers, game.Headers) {
+		t.Errorf("got headers %v, expected %v", parsed.Headers, game.Headers)
+	}
+	if !reflect.DeepEqual(parsed.Moves, game.Moves) {
+		t.Errorf("moves changed:\n%s\nexpected\n%s", parsed.Notation(), game.Notation())
+	}
+}
+
+func TestGameNotationRequiresScore(t *testing.T) {
+	board := RandomBoard(rand.New(rand.NewSource(1)))
+	notation := fmt.Sprintf("[Board %q]\n1. B 8,0-9,0-10,1 CAT +9,0=E\n", FormatBoard(board))
+	if _, err := ParseGameNotation(strings.NewReader(notation)); err == nil {
+		t.Error("parsed a move without a score")
+	}
+}
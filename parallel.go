@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rootPool searches the moves at the root of the tree in parallel. Each
+// worker has its own copy of the board and its own shard of the
+// transposition table.
+//
+// The first move is searched on its own to get a bound (with -multipv n, the
+// first n moves), then the other moves are handed out in batches of one move
+// per worker, in the order they are searched in, and the alpha-beta bounds
+// found by a batch are shared with the next one. The window a move is searched with and the table entries
+// it sees don't depend on which worker finishes first, so a search limited by
+// depth is deterministic for a given number of threads. A search limited by
+// time isn't, since where the deadline cuts an iteration depends on the clock.
+//
+// Only the root is split between workers: each move below it is searched by
+// a single worker, and bounds are only shared between batches. Splitting the
+// tree below the root would keep more cores busy on narrow positions, but
+// would make the result depend on timing.
+type rootPool struct {
+	workers []*rootWorker
+	results []*MinimaxResult
+	wg      sync.WaitGroup
+}
+
+type rootWorker struct {
+	searcher *searcher
+	board    *Board
+	jobs     chan rootJob
+}
+
+// rootJob is a move at the root to search with the window of its batch.
+type rootJob struct {
+	index    int
+	word     *Word
+	mover    Mover
+	alpha    float64
+	beta     float64
+	depth    int
+	deadline time.Time
+}
+
+func newRootPool(board *Board, trie *Trie, evaluator Evaluator, table *TranspositionTable, threads int) *rootPool {
+	if threads < 1 {
+		threads = 1
+	}
+	tables := make([]*TranspositionTable, threads)
+	if table != nil {
+		tables = table.Shards(threads)
+	}
+	pool := &rootPool{
+		workers: make([]*rootWorker, threads),
+		results: make([]*MinimaxResult, threads),
+	}
+	for idx := range pool.workers {
+		worker := &rootWorker{
+			searcher: &searcher{trie: trie, evaluator: evaluator, table: tables[idx], stats: &SearchStats{}},
+			board:    board.clone(),
+			jobs:     make(chan rootJob),
+		}
+		pool.workers[idx] = worker
+		go worker.run(pool)
+	}
+	return pool
+}
+
+func (w *rootWorker) run(pool *rootPool) {
+	for job := range w.jobs {
+		w.searcher.side = job.mover
+		w.searcher.deadline = job.deadline
+		delta, err := w.board.MakeMove(job.word, job.mover)
+		if err != nil {
+			pool.results[job.index] = nil
+			pool.wg.Done()
+			continue
+		}
+		moves := []*Move{{word: job.word, Mover: job.mover}}
+		pool.results[job.index] = w.searcher.runMinimax(w.board, job.mover.Opposite(), job.alpha, job.beta, job.depth, moves, job.word.Probability)
+		w.board.UnmakeMove(delta)
+		pool.wg.Done()
+	}
+}
+
+// search searches a batch of at most one word per worker and returns the
+// result of each word, or nil if the word couldn't be played.
+func (p *rootPool) search(words []*Word, mover Mover, alpha float64, beta float64, depth int, deadline time.Time) []*MinimaxResult {
+	p.wg.Add(len(words))
+	for idx, word := range words {
+		p.workers[idx].jobs <- rootJob{index: idx, word: word, mover: mover, alpha: alpha, beta: beta, depth: depth, deadline: deadline}
+	}
+	p.wg.Wait()
+	return p.results[:len(words)]
+}
+
+// Reports whether a worker ran out of time during the last batch.
+func (p *rootPool) aborted() bool {
+	for _, worker := range p.workers {
+		if worker.searcher.aborted {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *rootPool) size() int {
+	return len(p.workers)
+}
+
+// close stops the workers.
+func (p *rootPool) close() {
+	for _, worker := range p.workers {
+		close(worker.jobs)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import "math/rand"
+
+// Moves after which a game played by PlayGame is stopped and scored as a draw.
+const MAX_GAME_MOVES = 100
+
+// Player is an engine and the options it searches with.
+type Player struct {
+	Engine  Engine
+	Options SearchOptions
+	Table   *TranspositionTable
+}
+
+// PlayGame plays a game from the board, with each side's moves chosen by its
+// player. After every move, letters drawn from RefillDistribution are dealt
+// into the cleared tiles, as the game does. The game ends when a side reaches
+// the winning score, when neither side can move or after maxMoves moves.
+//
+// visit is called with the board before every move, with ToMove set to the
+// side to move, if it isn't nil. The board must not be modified. PlayGame
+// returns the record of the game and the board it ended on.
+func PlayGame(board *Board, trie *Trie, blue *Player, red *Player, maxMoves int, rng *rand.Rand, visit func(board *Board)) (*Game, *Board) {
+	game := NewGame(board)
+	board = board.clone()
+	mover := board.SideToMove()
+	passes := 0
+	for len(game.Moves) < maxMoves && board.GetTerminalResult(BlueMover) == -1 {
+		player := blue
+		if mover == RedMover {
+			player = red
+		}
+		board.ToMove = mover
+		if visit != nil {
+			visit(board)
+		}
+
+		options := player.Options
+		options.Seed = rng.Int63()
+		move := player.Engine(board, trie, options, player.Table)
+		if move == nil {
+			// Neither side can move.
+			passes++
+			if passes == 2 {
+				break
+			}
+			mover = mover.Opposite()
+			continue
+		}
+		passes = 0
+
+		if _, err := board.MakeMove(move.word, mover); err != nil {
+			panic(err)
+		}
+		gameMove := game.Record(move, board)
+		for _, node := range board.nodesFlat() {
+			if node.cleared {
+				letter := RefillDistribution.Sample(rng)
+				if err := board.Refill(node.coords, letter); err != nil {
+					panic(err)
+				}
+				gameMove.Refills = append(gameMove.Refills, Refill{Coords: node.coords, Char: string(letter)})
+			}
+		}
+		mover = mover.Opposite()
+	}
+	board.ToMove = mover
+	return game, board
+}
+
+// GameResult returns 1 if blue won the game that ended on the board, 0 if red
+// won and 0.5 if neither did.
+func GameResult(board *Board) float64 {
+	result := board.GetTerminalResult(BlueMover)
+	if result == -1 {
+		return 0.5
+	}
+	return result
+}
+
+// RandomBoard makes a board of grey tiles with letters drawn from RefillDistribution.
+func RandomBoard(rng *rand.Rand) *Board {
+	geometry := GetGeometry(BOARD_RADIUS)
+	board := &Board{Nodes: make([][]*BoardNode, geometry.NumLines())}
+	for lineNum := range board.Nodes {
+		board.Nodes[lineNum] = make([]*BoardNode, geometry.LineLength(lineNum))
+		for col := range board.Nodes[lineNum] {
+			letter := RefillDistribution.Sample(rng)
+			board.Nodes[lineNum][col] = &BoardNode{Letter: letter, Char: string(letter), Color: None}
+		}
+	}
+	board.Initialize()
+	return board
+}
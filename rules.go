@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrSwapsNotAllowed = errors.New("swaps are not allowed")
+	ErrSwapNotOntoGrey = errors.New("tiles can only be swapped onto grey tiles")
+)
+
+// Rules decides how hexagons are captured, when the game is won and which
+// swaps are allowed, so the same search can be run against variants of the game.
+type Rules interface {
+	// Points needed to win the game.
+	WinningScore() int
+	// HexagonOwner returns the side that captures the hexagon centered on the node, or "" if it isn't captured.
+	HexagonOwner(board *Board, center *BoardNode) Mover
+	// CaptureHexagon updates the hexagon centered on the node after mover's move captured it.
+	CaptureHexagon(board *Board, center *BoardNode, mover Mover)
+	// IsSuperHexagon reports whether the hexagon centered on the node is a super hexagon,
+	// which is cleared after the hexagons captured by a move.
+	IsSuperHexagon(board *Board, center *BoardNode) bool
+	ClearSuperHexagon(board *Board, center *BoardNode)
+	// CanSwap returns nil if the tile can be swapped with its neighbor.
+	CanSwap(board *Board, node *BoardNode, neighbor *BoardNode) error
+}
+
+// HexiconRules are the rules of Hexicon, with settings for common house variants.
+type HexiconRules struct {
+	Points int
+	// Don't allow any swaps.
+	NoSwaps bool
+	// Only allow swapping a tile with a grey tile. FindWords only tries swaps
+	// onto grey tiles anyway, so this doesn't change what the search plays: it
+	// only makes ValidateMove reject the other swaps.
+	GreySwapsOnly bool
+}
+
+var DefaultRules Rules = &HexiconRules{Points: 16}
+
+// Variants of the rules that can be selected by name. greyswap searches like
+// hexicon and only differs in the moves ValidateMove accepts.
+var RuleVariants = map[string]Rules{
+	"hexicon":  DefaultRules,
+	"short":    &HexiconRules{Points: 10},
+	"noswap":   &HexiconRules{Points: 16, NoSwaps: true},
+	"greyswap": &HexiconRules{Points: 16, GreySwapsOnly: true},
+}
+
+func GetRules(name string) (Rules, error) {
+	rules, ok := RuleVariants[name]
+	if !ok {
+		names := make([]string, 0, len(RuleVariants))
+		for name := range RuleVariants {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown rules %q, expected one of %v", name, names)
+	}
+	return rules, nil
+}
+
+func (r *HexiconRules) WinningScore() int {
+	return r.Points
+}
+
+func (r *HexiconRules) HexagonOwner(board *Board, center *BoardNode) Mover {
+	if board.bits != nil {
+		return board.bits.HexagonOwner(center.index)
+	}
+	return center.checkHexagon(board)
+}
+
+func (r *HexiconRules) CaptureHexagon(board *Board, center *BoardNode, mover Mover) {
+	center.clearHexagon(board, mover)
+}
+
+func (r *HexiconRules) IsSuperHexagon(board *Board, center *BoardNode) bool {
+	if board.bits != nil {
+		return board.bits.IsSuperHexagon(center.index)
+	}
+	return center.isSuperHexagon(board)
+}
+
+func (r *HexiconRules) ClearSuperHexagon(board *Board, center *BoardNode) {
+	center.clearSuperHexagon(board)
+}
+
+// Only one swap is allowed per turn, and captured tiles can't be moved.
+func (r *HexiconRules) CanSwap(board *Board, node *BoardNode, neighbor *BoardNode) error {
+	if r.NoSwaps {
+		return ErrSwapsNotAllowed
+	}
+	if board.HasSwapped {
+		return ErrAlreadySwapped
+	}
+	for _, n := range []*BoardNode{node, neighbor} {
+		if n.Color == VeryBlue || n.Color == VeryRed {
+			return &TileError{Coords: n.coords, Err: ErrTileCaptured}
+		}
+	}
+	if r.GreySwapsOnly && neighbor.Color != None {
+		return &TileError{Coords: neighbor.coords, Err: ErrSwapNotOntoGrey}
+	}
+	return nil
+}
+
+// Rules the board is played with.
+func (b *Board) Rules() Rules {
+	if b.rules == nil {
+		return DefaultRules
+	}
+	return b.rules
+}
+
+func (b *Board) SetRules(rules Rules) {
+	b.rules = rules
+	if b.bits != nil {
+		b.bits.rules = rules
+	}
+}
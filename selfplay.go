@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// PlayerConfig selects an engine and how it searches, stored as JSON:
+//
+//	{"engine": "mcts", "time": "1s", "playout": "greedy", "eval": "tuned.json"}
+//
+// Settings that are left out take the same defaults as the solver's flags.
+type PlayerConfig struct {
+	// Name of the engine in Engines, "minimax" if empty.
+	Engine      string  `json:"engine"`
+	Depth       int     `json:"depth,omitempty"`
+	Time        string  `json:"time,omitempty"`
+	Iterations  int     `json:"iterations,omitempty"`
+	Exploration float64 `json:"exploration,omitempty"`
+	Playout     string  `json:"playout,omitempty"`
+	// Path of an EvaluatorConfig, see LoadDefaultEvaluatorConfig if empty.
+	Eval string `json:"eval,omitempty"`
+}
+
+func LoadPlayerConfig(path string) (*PlayerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &PlayerConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// NewPlayer makes the player the config describes, searching on threads
+// cores with a transposition table of tableSize megabytes.
+func (c *PlayerConfig) NewPlayer(trie *Trie, threads int, tableSize int) (*Player, error) {
+	name := c.Engine
+	if name == "" {
+		name = "minimax"
+	}
+	engine, err := GetEngine(name)
+	if err != nil {
+		return nil, err
+	}
+	options := SearchOptions{
+		Depth:       c.Depth,
+		Threads:     threads,
+		Iterations:  c.Iterations,
+		Exploration: c.Exploration,
+		Playout:     c.Playout,
+	}
+	if c.Time != "" {
+		if options.Time, err = time.ParseDuration(c.Time); err != nil {
+			return nil, err
+		}
+	}
+	path := c.Eval
+	config, err := LoadDefaultEvaluatorConfig()
+	if path == "" {
+		path = DEFAULT_EVALUATOR_CONFIG
+	} else {
+		config, err = LoadEvaluatorConfig(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if options.Evaluator, err = config.NewEvaluator(trie); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Player{Engine: engine, Options: options, Table: NewTranspositionTable(tableSize)}, nil
+}
+
+// MatchResult counts the games of a match from the first player's point of view.
+type MatchResult struct {
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+func (r *MatchResult) Games() int {
+	return r.Wins + r.Losses + r.Draws
+}
+
+// Add counts a game the first player scored result in: 1 for a win, 0 for a
+// loss and 0.5 for a draw.
+func (r *MatchResult) Add(result float64) {
+	switch result {
+	case 1:
+		r.Wins++
+	case 0:
+		r.Losses++
+	default:
+		r.Draws++
+	}
+}
+
+// Score is the share of the points the first player made, counting draws as half a point.
+func (r *MatchResult) Score() float64 {
+	if r.Games() == 0 {
+		return 0.5
+	}
+	return (float64(r.Wins) + float64(r.Draws)/2) / float64(r.Games())
+}
+
+// ScoreInterval is the confidence interval of the score for the normal
+// quantile z (1.96 for 95%), from the spread of the results of the games
+// (1, 0.5 or 0 points each). Like the Agresti-Coull interval for a share, it
+// first counts z*z more games, half of them won and half lost, so the
+// interval doesn't shrink to nothing when every game has the same result.
+// Without draws, it is the Agresti-Coull interval of the share of wins.
+func (r *MatchResult) ScoreInterval(z float64) (float64, float64) {
+	extra := z * z / 2
+	wins := float64(r.Wins) + extra
+	losses := float64(r.Losses) + extra
+	draws := float64(r.Draws)
+	n := wins + losses + draws
+	score := (wins + draws/2) / n
+	variance := (wins*(1-score)*(1-score) + draws*(0.5-score)*(0.5-score) + losses*score*score) / n
+	margin := z * math.Sqrt(variance/n)
+	return math.Max(score-margin, 0), math.Min(score+margin, 1)
+}
+
+// WilsonInterval is the Wilson score interval of the share of n games that
+// count of them make up, for the normal quantile z. Unlike the normal
+// approximation, it stays meaningful when count is 0 or n.
+func WilsonInterval(count int, n int, z float64) (float64, float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	p := float64(count) / float64(n)
+	total := float64(n)
+	center := (p + z*z/(2*total)) / (1 + z*z/total)
+	margin := z / (1 + z*z/total) * math.Sqrt(p*(1-p)/total+z*z/(4*total*total))
+	return math.Max(center-margin, 0), math.Min(center+margin, 1)
+}
+
+// Elo converts a score to the difference of Elo ratings that predicts it.
+func Elo(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+	return 400 * math.Log10(score/(1-score))
+}
+
+// Normal quantile of the 95% confidence intervals of the match results.
+const CONFIDENCE_Z = 1.96
+
+func (r *MatchResult) String() string {
+	var builder strings.Builder
+	n := r.Games()
+	fmt.Fprintf(&builder, "%d games: %d wins, %d losses, %d draws\n", n, r.Wins, r.Losses, r.Draws)
+	for _, line := range []struct {
+		name  string
+		count int
+	}{{"Wins", r.Wins}, {"Losses", r.Losses}, {"Draws", r.Draws}} {
+		low, high := WilsonInterval(line.count, n, CONFIDENCE_Z)
+		share := 0.0
+		if n > 0 {
+			share = float64(line.count) / float64(n)
+		}
+		fmt.Fprintf(&builder, "%-7s %5.1f%%  (95%% CI %5.1f%% - %5.1f%%)\n", line.name, 100*share, 100*low, 100*high)
+	}
+	low, high := r.ScoreInterval(CONFIDENCE_Z)
+	fmt.Fprintf(&builder, "%-7s %5.1f%%  (95%% CI %5.1f%% - %5.1f%%)\n", "Score", 100*r.Score(), 100*low, 100*high)
+	fmt.Fprintf(&builder, "%-7s %s   (95%% CI %s - %s)", "Elo", formatElo(r.Score()), formatElo(low), formatElo(high))
+	return builder.String()
+}
+
+// formatElo prints the Elo difference of a score, or n/a for the infinite one
+// of a score of 0 or 1.
+func formatElo(score float64) string {
+	if score <= 0 || score >= 1 {
+		return "  n/a"
+	}
+	return fmt.Sprintf("%+5.0f", Elo(score))
+}
+
+// PlayMatch plays two games from each board between the players, one with
+// each of them as blue, and returns the results for the first player. Both
+// games of a pair draw refills from the same seed, which evens out the luck
+// of the letters. report is called after every game if it isn't nil.
+func PlayMatch(boards []*Board, trie *Trie, first *Player, second *Player, maxMoves int, seed int64, report func(game *Game, result float64)) *MatchResult {
+	result := &MatchResult{}
+	for idx, board := range boards {
+		for _, firstIsBlue := range []bool{true, false} {
+			rng := rand.New(rand.NewSource(seed + int64(idx)))
+			blue, red := first, second
+			if !firstIsBlue {
+				blue, red = second, first
+			}
+			game, end := PlayGame(board, trie, blue, red, maxMoves, rng, nil)
+			gameResult := GameResult(end)
+			if !firstIsBlue {
+				gameResult = 1 - gameResult
+			}
+			result.Add(gameResult)
+			if report != nil {
+				report(game, gameResult)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreInterval(t *testing.T) {
+	for _, test := range []struct {
+		result    MatchResult
+		low, high float64
+	}{
+		// The interval of 20 games, not of 40 half points.
+		{MatchResult{Wins: 10, Losses: 10}, 0.299, 0.701},
+		// Every game won still leaves room for a weaker player.
+		{MatchResult{Wins: 4}, 0.454, 1},
+		{MatchResult{Losses: 5}, 0, 0.489},
+		// Draws spread the results less than wins and losses do.
+		{MatchResult{Wins: 5, Losses: 5, Draws: 10}, 0.347, 0.653},
+		{MatchResult{}, 0, 1},
+	} {
+		low, high := test.result.ScoreInterval(CONFIDENCE_Z)
+		if math.Abs(low-test.low) > 0.001 || math.Abs(high-test.high) > 0.001 {
+			t.Errorf("%+v: got %.3f - %.3f, expected %.3f - %.3f", test.result, low, high, test.low, test.high)
+		}
+	}
+}
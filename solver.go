@@ -5,14 +5,31 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"strings"
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 var memprofile = flag.String("memprofile", "", "write memory profile to `file`")
+var rulesName = flag.String("rules", "hexicon", "rules variant to play with: hexicon, short, noswap or greyswap")
+var searchTime = flag.Duration("time", 0, "search for at most `duration`, deepening the search until it runs out")
+var searchDepth = flag.Int("depth", 0, "search at most `n` moves deep (default 2 without -time)")
+var tableSize = flag.Int("hash", 64, "size of the transposition table in `MB`")
+var threads = flag.Int("threads", runtime.NumCPU(), "number of moves searched in parallel")
+var engineName = flag.String("engine", "minimax", "search engine: minimax or mcts")
+var iterations = flag.Int("iterations", 0, "run `n` iterations of MCTS (default 200 without -time)")
+var exploration = flag.Float64("exploration", MCTS_EXPLORATION, "weight of the exploration term of MCTS")
+var playout = flag.String("playout", RandomPlayout, "how MCTS plays games out: random or greedy")
+var mover = flag.String("mover", "", "side to move, red or blue (default the board's toMove, or blue)")
+var multiPV = flag.Int("multipv", 0, "show the `n` best moves with their scores and principal variations")
+var statsFormat = flag.String("stats", "", "print statistics of the search as `text` or json")
+var evalConfig = flag.String("eval", DEFAULT_EVALUATOR_CONFIG, "load the evaluator and its weights from a JSON `file`, the built-in weights if the default file is missing")
+var lettersFile = flag.String("letters", "", "load the distribution of refilled letters from `file`, see the letters command")
 
 func main() {
 	flag.Parse()
@@ -28,14 +45,180 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	board := &Board{}
+	if *lettersFile != "" {
+		distribution, err := LoadLetterDistribution(*lettersFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		RefillDistribution = distribution
+	}
+
+	switch flag.Arg(0) {
+	case "":
+	case "replay":
+		replay(flag.Args()[1:])
+		return
+	case "letters":
+		estimateLetters(flag.Args()[1:])
+		return
+	case "tune":
+		tune(flag.Args()[1:])
+		return
+	case "selfplay":
+		selfplay(flag.Args()[1:])
+		return
+	default:
+		log.Fatalf("unknown command: %s", flag.Arg(0))
+	}
+
+	board, err := readBoard(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := GetRules(*rulesName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	board.SetRules(rules)
+	if *mover != "" {
+		board.ToMove = Mover(*mover)
+		if err := board.Validate(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	engine, err := GetEngine(*engineName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trie := loadTrie()
+	table := NewTranspositionTable(*tableSize)
+	options := searchOptions(loadEvaluator(trie))
+	switch *statsFormat {
+	case "":
+	case "text", "json":
+		options.Stats = &SearchStats{}
+	default:
+		log.Fatalf("unknown stats format: %s", *statsFormat)
+	}
+	if *multiPV > 0 {
+		if *engineName != "minimax" {
+			log.Fatal("-multipv is only supported by the minimax engine")
+		}
+		printMultiPV(board, trie, options, table)
+		printStats(options.Stats)
+		return
+	}
+
+	result := engine(board, trie, options, table)
+	if result == nil {
+		log.Fatal("no moves found")
+	}
+
+	if _, err := board.MakeMove(result.word, result.Mover); err != nil {
+		log.Fatal(err)
+	}
+	// Print the result
+	fmt.Println(result.String(board))
+	fmt.Println(result.Notation())
+	if *engineName == "minimax" {
+		fmt.Println(table)
+	}
+	printStats(options.Stats)
+
+	if *memprofile != "" {
+		f, err := os.Create(*memprofile)
+		if err != nil {
+			log.Fatal("could not create memory profile: ", err)
+		}
+		defer f.Close() // error handling omitted for example
+		runtime.GC()    // get up-to-date statistics
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			log.Fatal("could not write memory profile: ", err)
+		}
+	}
+}
 
-	err := json.NewDecoder(os.Stdin).Decode(board)
+// Loads the -eval config.
+func loadEvaluatorConfig() *EvaluatorConfig {
+	var config *EvaluatorConfig
+	var err error
+	if *evalConfig == DEFAULT_EVALUATOR_CONFIG {
+		config, err = LoadDefaultEvaluatorConfig()
+	} else {
+		config, err = LoadEvaluatorConfig(*evalConfig)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+	return config
+}
+
+// Loads the evaluator of the -eval config.
+func loadEvaluator(trie *Trie) Evaluator {
+	evaluator, err := loadEvaluatorConfig().NewEvaluator(trie)
+	if err != nil {
+		log.Fatalf("%s: %s", *evalConfig, err)
+	}
+	return evaluator
+}
+
+// Options of the search, from the flags.
+func searchOptions(evaluator Evaluator) SearchOptions {
+	return SearchOptions{
+		Depth:       *searchDepth,
+		Time:        *searchTime,
+		Evaluator:   evaluator,
+		Threads:     *threads,
+		MultiPV:     *multiPV,
+		Iterations:  *iterations,
+		Exploration: *exploration,
+		Playout:     *playout,
+	}
+}
+
+// Prints the best moves with their principal variations.
+func printMultiPV(board *Board, trie *Trie, options SearchOptions, table *TranspositionTable) {
+	lines := ExecuteMultiPV(board, trie, options, table)
+	if len(lines) == 0 || len(lines[0].moves) == 0 {
+		log.Fatal("no moves found")
+	}
+	for idx, line := range lines {
+		fmt.Printf("=== Move %d of %d ===\n", idx+1, len(lines))
+		fmt.Println(line.Variation(board))
+	}
+	fmt.Println(table)
+}
+
+func printStats(stats *SearchStats) {
+	if stats == nil {
+		return
+	}
+	if *statsFormat == "json" {
+		data, err := stats.JSON()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Println(stats)
+}
+
+// Reads a board in the JSON format written by `yarn extract`.
+func readBoard(r io.Reader) (*Board, error) {
+	board := &Board{}
+	if err := json.NewDecoder(r).Decode(board); err != nil {
+		return nil, err
+	}
+	if err := board.Validate(); err != nil {
+		return nil, err
+	}
 	board.Initialize()
+	return board, nil
+}
 
+func loadTrie() *Trie {
 	// Read the word list from the file
 	file, err := os.Open("word_list.txt")
 	if err != nil {
@@ -49,25 +232,207 @@ func main() {
 	}
 
 	// Instantiate the trie
-	trie := CreateTrie(words)
+	return CreateTrie(words)
+}
 
-	result := ExecuteMinimax(board, trie)
+// Replay and verify recorded games, printing the final board of each.
+func replay(paths []string) {
+	trie := loadTrie()
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var game *Game
+		if strings.HasSuffix(path, ".json") {
+			game = &Game{}
+			err = json.NewDecoder(file).Decode(game)
+		} else {
+			game, err = ParseGameNotation(file)
+		}
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", path, err)
+		}
 
-	if len(result.word.SwappedNodes) > 0 {
-		board.SwapNodes(result.word.SwappedNodes[0], result.word.SwappedNodes[1], false)
+		board, err := game.Replay(trie)
+		if err != nil {
+			log.Fatalf("%s: %s", path, err)
+		}
+		fmt.Printf("%s: %d moves OK\n", path, len(game.Moves))
+		fmt.Println(board.String())
 	}
-	// Print the result
-	fmt.Println(result.String(nil))
+}
 
-	if *memprofile != "" {
-		f, err := os.Create(*memprofile)
+// Estimate the distribution of refilled letters from boards and save it to the first file.
+func estimateLetters(args []string) {
+	if len(args) < 2 {
+		log.Fatal("usage: letters <output.json> <board.json>...")
+	}
+	boards := make([]*Board, 0, len(args)-1)
+	for _, path := range args[1:] {
+		file, err := os.Open(path)
 		if err != nil {
-			log.Fatal("could not create memory profile: ", err)
+			log.Fatal(err)
 		}
-		defer f.Close() // error handling omitted for example
-		runtime.GC()    // get up-to-date statistics
-		if err := pprof.WriteHeapProfile(f); err != nil {
-			log.Fatal("could not write memory profile: ", err)
+		board, err := readBoard(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", path, err)
 		}
+		boards = append(boards, board)
 	}
+
+	distribution := EstimateLetterDistribution(boards)
+	if err := distribution.Save(args[0]); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Estimated the letter distribution from %d boards\n", len(boards))
+}
+
+// Boards to start count games from: the boards at the paths in turn, or random boards.
+func startingBoards(paths []string, count int, rules Rules, rng *rand.Rand) []*Board {
+	starts := make([]*Board, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		board, err := readBoard(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %s", path, err)
+		}
+		starts = append(starts, board)
+	}
+	boards := make([]*Board, 0, count)
+	for idx := 0; idx < count; idx++ {
+		var board *Board
+		if len(starts) > 0 {
+			board = starts[idx%len(starts)]
+		} else {
+			board = RandomBoard(rng)
+		}
+		board.SetRules(rules)
+		boards = append(boards, board)
+	}
+	return boards
+}
+
+// Play games with the engine of the flags and fit the weights of the linear
+// evaluator to their results, saving them to a config for -eval.
+func tune(args []string) {
+	flags := flag.NewFlagSet("tune", flag.ExitOnError)
+	games := flags.Int("games", 20, "number of games to play")
+	maxMoves := flags.Int("moves", MAX_GAME_MOVES, "stop games after `n` moves and score them as draws")
+	seed := flags.Int64("seed", 1, "seed of the refills and generated boards")
+	output := flags.String("o", *evalConfig, "save the tuned weights to `file`, by default the one -eval loads them from")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tune [-games n] [-moves n] [-seed n] [-o file] [board.json...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	rules, err := GetRules(*rulesName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	engine, err := GetEngine(*engineName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	trie := loadTrie()
+	config := loadEvaluatorConfig()
+	if config.Evaluator != "" && config.Evaluator != "linear" {
+		log.Fatalf("only the linear evaluator can be tuned, not %q", config.Evaluator)
+	}
+	evaluator, err := config.NewEvaluator(trie)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	boards := startingBoards(flags.Args(), *games, rules, rng)
+	player := &Player{Engine: engine, Options: searchOptions(evaluator), Table: NewTranspositionTable(*tableSize)}
+	samples, results := CollectTuningSamples(boards, trie, player, *maxMoves, rng)
+	wins := 0
+	draws := 0
+	for _, result := range results {
+		if result == 1 {
+			wins++
+		} else if result == 0.5 {
+			draws++
+		}
+	}
+	fmt.Printf("Played %d games: %d won by blue, %d by red, %d drawn\n", len(results), wins, len(results)-wins-draws, draws)
+
+	initial := make([]float64, len(Features))
+	for idx, feature := range Features {
+		initial[idx] = config.Weights[feature.Name]
+	}
+	before := TuningError(samples, initial)
+	tuned, after := TuneWeights(samples, config.Weights)
+	fmt.Printf("Fitted %d positions: error %.4f before, %.4f after\n", len(samples)/2, before, after)
+
+	config = &EvaluatorConfig{Evaluator: "linear", Weights: tuned}
+	if err := config.Save(*output); err != nil {
+		log.Fatal(err)
+	}
+	for _, feature := range Features {
+		fmt.Printf("%-24s %.4f\n", feature.Name, tuned[feature.Name])
+	}
+	fmt.Printf("Saved the weights to %s\n", *output)
+}
+
+// Play a match between two engine configs and report the results of the first.
+func selfplay(args []string) {
+	flags := flag.NewFlagSet("selfplay", flag.ExitOnError)
+	games := flags.Int("games", 20, "number of games to play, rounded up to an even number so both configs play each board as both sides")
+	maxMoves := flags.Int("moves", MAX_GAME_MOVES, "stop games after `n` moves and score them as draws")
+	seed := flags.Int64("seed", 1, "seed of the refills and generated boards")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: selfplay [-games n] [-moves n] [-seed n] <first.json> <second.json> [board.json...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	rules, err := GetRules(*rulesName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	trie := loadTrie()
+	players := make([]*Player, 2)
+	for idx, path := range flags.Args()[:2] {
+		config, err := LoadPlayerConfig(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if players[idx], err = config.NewPlayer(trie, *threads, *tableSize); err != nil {
+			log.Fatalf("%s: %s", path, err)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	boards := startingBoards(flags.Args()[2:], (*games+1)/2, rules, rng)
+	played := 0
+	result := PlayMatch(boards, trie, players[0], players[1], *maxMoves, *seed, func(game *Game, result float64) {
+		played++
+		outcome := "draw"
+		if result == 1 {
+			outcome = "win"
+		} else if result == 0 {
+			outcome = "loss"
+		}
+		side := "blue"
+		if played%2 == 0 {
+			side = "red"
+		}
+		fmt.Printf("Game %d: %s as %s in %d moves\n", played, outcome, side, len(game.Moves))
+	})
+	fmt.Printf("%s vs %s, ", flags.Arg(0), flags.Arg(1))
+	fmt.Println(result)
 }
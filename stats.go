@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchStats counts the work an engine did, to tune it with.
+type SearchStats struct {
+	// Deepest iteration that completed.
+	Depth int `json:"depth"`
+	// Nodes visited at each ply, the root being ply 0.
+	Nodes []int64 `json:"nodes"`
+	// Calls to FindWords and the words they returned.
+	FindWordsCalls int64 `json:"findWordsCalls"`
+	WordsGenerated int64 `json:"wordsGenerated"`
+	// Nodes whose moves were searched, and how many moves were searched at them.
+	ExpandedNodes int64 `json:"expandedNodes"`
+	SearchedMoves int64 `json:"searchedMoves"`
+	// Alpha-beta cutoffs, by the index of the move that caused them in the
+	// order the moves were searched.
+	Cutoffs []int64 `json:"cutoffs"`
+	// Nodes that weren't searched because they were too unlikely to be reached.
+	ProbabilityPrunes int64 `json:"probabilityPrunes"`
+	// Words through cleared tiles that were left out, see MAX_CHANCE_WORDS.
+	ChanceWordsDropped int64         `json:"chanceWordsDropped"`
+	Elapsed            time.Duration `json:"elapsedNs"`
+}
+
+// Grows the counts so that there is one at idx.
+func growCounts(counts []int64, idx int) []int64 {
+	for len(counts) <= idx {
+		counts = append(counts, 0)
+	}
+	return counts
+}
+
+func (s *SearchStats) visit(ply int) {
+	s.Nodes = growCounts(s.Nodes, ply)
+	s.Nodes[ply]++
+}
+
+func (s *SearchStats) generated(words []*Word) {
+	s.FindWordsCalls++
+	s.WordsGenerated += int64(len(words))
+}
+
+func (s *SearchStats) cutoff(moveIndex int) {
+	s.Cutoffs = growCounts(s.Cutoffs, moveIndex)
+	s.Cutoffs[moveIndex]++
+}
+
+// Add adds the counts of other to the stats.
+func (s *SearchStats) Add(other *SearchStats) {
+	if other.Depth > s.Depth {
+		s.Depth = other.Depth
+	}
+	for ply, count := range other.Nodes {
+		s.Nodes = growCounts(s.Nodes, ply)
+		s.Nodes[ply] += count
+	}
+	s.FindWordsCalls += other.FindWordsCalls
+	s.WordsGenerated += other.WordsGenerated
+	s.ExpandedNodes += other.ExpandedNodes
+	s.SearchedMoves += other.SearchedMoves
+	for idx, count := range other.Cutoffs {
+		s.Cutoffs = growCounts(s.Cutoffs, idx)
+		s.Cutoffs[idx] += count
+	}
+	s.ProbabilityPrunes += other.ProbabilityPrunes
+	s.ChanceWordsDropped += other.ChanceWordsDropped
+	s.Elapsed += other.Elapsed
+}
+
+func (s *SearchStats) TotalNodes() int64 {
+	total := int64(0)
+	for _, count := range s.Nodes {
+		total += count
+	}
+	return total
+}
+
+func (s *SearchStats) TotalCutoffs() int64 {
+	total := int64(0)
+	for _, count := range s.Cutoffs {
+		total += count
+	}
+	return total
+}
+
+// BranchingFactor is the average number of words found at a node.
+func (s *SearchStats) BranchingFactor() float64 {
+	if s.FindWordsCalls == 0 {
+		return 0
+	}
+	return float64(s.WordsGenerated) / float64(s.FindWordsCalls)
+}
+
+// EffectiveBranchingFactor is the average number of moves searched at a
+// node, which is lower than the branching factor when the cutoffs work.
+func (s *SearchStats) EffectiveBranchingFactor() float64 {
+	if s.ExpandedNodes == 0 {
+		return 0
+	}
+	return float64(s.SearchedMoves) / float64(s.ExpandedNodes)
+}
+
+func (s *SearchStats) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Depth: %d, %s\n", s.Depth, s.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(&builder, "Nodes: %d", s.TotalNodes())
+	for ply, count := range s.Nodes {
+		fmt.Fprintf(&builder, ", %d at ply %d", count, ply)
+	}
+	if seconds := s.Elapsed.Seconds(); seconds > 0 {
+		fmt.Fprintf(&builder, " (%.0f nodes/s)", float64(s.TotalNodes())/seconds)
+	}
+	builder.WriteString("\n")
+	fmt.Fprintf(&builder, "Words: %d from %d calls to FindWords\n", s.WordsGenerated, s.FindWordsCalls)
+	fmt.Fprintf(&builder, "Branching factor: %.1f, %.1f searched\n", s.BranchingFactor(), s.EffectiveBranchingFactor())
+
+	cutoffs := s.TotalCutoffs()
+	fmt.Fprintf(&builder, "Cutoffs: %d", cutoffs)
+	// The first few moves show how good the move ordering is.
+	later := int64(0)
+	for idx, count := range s.Cutoffs {
+		if idx < 3 {
+			fmt.Fprintf(&builder, ", %.1f%% at move %d", 100*float64(count)/float64(cutoffs), idx+1)
+		} else {
+			later += count
+		}
+	}
+	if later > 0 {
+		fmt.Fprintf(&builder, ", %.1f%% later", 100*float64(later)/float64(cutoffs))
+	}
+	builder.WriteString("\n")
+	fmt.Fprintf(&builder, "Probability prunes: %d, chance words dropped: %d", s.ProbabilityPrunes, s.ChanceWordsDropped)
+	return builder.String()
+}
+
+// JSON encodes the stats along with the totals computed from them.
+func (s *SearchStats) JSON() ([]byte, error) {
+	type stats SearchStats
+	return json.Marshal(struct {
+		*stats
+		TotalNodes               int64   `json:"totalNodes"`
+		TotalCutoffs             int64   `json:"totalCutoffs"`
+		BranchingFactor          float64 `json:"branchingFactor"`
+		EffectiveBranchingFactor float64 `json:"effectiveBranchingFactor"`
+	}{
+		stats:                    (*stats)(s),
+		TotalNodes:               s.TotalNodes(),
+		TotalCutoffs:             s.TotalCutoffs(),
+		BranchingFactor:          s.BranchingFactor(),
+		EffectiveBranchingFactor: s.EffectiveBranchingFactor(),
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import "fmt"
+
+// Bound tells how a score stored in the transposition table relates to the
+// real score of the position, depending on where it fell in the search window.
+type Bound uint8
+
+const (
+	// The score is exact.
+	ExactBound Bound = iota + 1
+	// The search was cut off, the real score is at least the stored one.
+	LowerBound
+	// No move reached alpha, the real score is at most the stored one.
+	UpperBound
+)
+
+type tableEntry struct {
+	key   uint64
+	depth int
+	bound Bound
+	score float64
+	move  *Word
+}
+
+// TranspositionTable remembers the results of searched positions, keyed by
+// their hash, so positions reached through different move orders are only
+// searched once. It has a fixed number of slots and a new result replaces
+// the one in its slot, unless that is a deeper result for the same position.
+type TranspositionTable struct {
+	entries []tableEntry
+	stats   tableStats
+	// Parts of the entries used by parallel searches, see Shards.
+	shards []*TranspositionTable
+}
+
+type tableStats struct {
+	probes  uint64
+	hits    uint64
+	cutoffs uint64
+	stores  uint64
+}
+
+func (s *tableStats) add(other tableStats) {
+	s.probes += other.probes
+	s.hits += other.hits
+	s.cutoffs += other.cutoffs
+	s.stores += other.stores
+}
+
+// Approximate size of an entry in bytes.
+const tableEntrySize = 40
+
+// NewTranspositionTable makes a table using at most sizeMB megabytes.
+func NewTranspositionTable(sizeMB int) *TranspositionTable {
+	slots := sizeMB << 20 / tableEntrySize
+	if slots < 1 {
+		slots = 1
+	}
+	return &TranspositionTable{entries: make([]tableEntry, slots)}
+}
+
+// Shards splits the slots of the table into n tables, one for each of n
+// searches running in parallel. A table isn't safe for concurrent use, and
+// searches that don't share entries can't affect each other's results.
+func (t *TranspositionTable) Shards(n int) []*TranspositionTable {
+	if n <= 1 {
+		return []*TranspositionTable{t}
+	}
+	if len(t.shards) == n {
+		return t.shards
+	}
+	for _, shard := range t.shards {
+		t.stats.add(shard.stats)
+	}
+	if len(t.entries) < n {
+		t.entries = make([]tableEntry, n)
+	}
+	t.shards = make([]*TranspositionTable, n)
+	size := len(t.entries) / n
+	for idx := range t.shards {
+		t.shards[idx] = &TranspositionTable{entries: t.entries[idx*size : (idx+1)*size : (idx+1)*size]}
+	}
+	return t.shards
+}
+
+func (t *TranspositionTable) slot(key uint64) *tableEntry {
+	return &t.entries[key%uint64(len(t.entries))]
+}
+
+// Probe looks up a position. ok is false if the position isn't in the table.
+func (t *TranspositionTable) Probe(key uint64) (entry tableEntry, ok bool) {
+	t.stats.probes++
+	entry = *t.slot(key)
+	if entry.bound == 0 || entry.key != key {
+		return tableEntry{}, false
+	}
+	t.stats.hits++
+	return entry, true
+}
+
+// Store saves the result of searching a position depth moves deep.
+func (t *TranspositionTable) Store(key uint64, depth int, bound Bound, score float64, move *Word) {
+	slot := t.slot(key)
+	if slot.key == key && slot.depth > depth {
+		return
+	}
+	t.stats.stores++
+	*slot = tableEntry{key: key, depth: depth, bound: bound, score: score, move: move}
+}
+
+// Score returns the stored score if it settles the position for the window.
+func (e *tableEntry) Score(depth int, alpha float64, beta float64) (float64, bool) {
+	if e.depth < depth {
+		return 0, false
+	}
+	switch e.bound {
+	case ExactBound:
+		return e.score, true
+	case LowerBound:
+		return e.score, e.score >= beta
+	case UpperBound:
+		return e.score, e.score <= alpha
+	}
+	return 0, false
+}
+
+// Statistics of the table and its shards.
+func (t *TranspositionTable) String() string {
+	stats := t.stats
+	for _, shard := range t.shards {
+		stats.add(shard.stats)
+	}
+	misses := stats.probes - stats.hits
+	hitRate := 0.0
+	if stats.probes > 0 {
+		hitRate = 100 * float64(stats.hits) / float64(stats.probes)
+	}
+	return fmt.Sprintf("Transposition table: %d probes, %d hits (%.1f%%), %d misses, %d cutoffs, %d stores",
+		stats.probes, stats.hits, hitRate, misses, stats.cutoffs, stats.stores)
+}
@@ -74,9 +74,8 @@ func (fr FindResult) String() string {
 
 func (t *Trie) Find(nodes []*AccumulatedNode) FindResult {
 	result := FindResult{
-		IsWord:      false,
-		IsPrefix:    false,
-		NextLetters: make(map[byte]bool, ALPHABET_SIZE),
+		IsWord:   false,
+		IsPrefix: false,
 	}
 	wordLength := len(nodes)
 	current := t.root
@@ -96,3 +95,31 @@ func (t *Trie) Find(nodes []*AccumulatedNode) FindResult {
 	}
 	return result
 }
+
+// NextLetters returns the letters that can follow the accumulated letters in a word.
+func (t *Trie) NextLetters(nodes []*AccumulatedNode) map[byte]bool {
+	current := t.root
+	for _, node := range nodes {
+		current = current.children[node.Letter-'A']
+		if current == nil {
+			return nil
+		}
+	}
+	return current.nextLetters
+}
+
+// Contains reports whether the word is in the dictionary.
+func (t *Trie) Contains(word string) bool {
+	word = strings.ToUpper(word)
+	current := t.root
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'A' || word[i] > 'Z' {
+			return false
+		}
+		current = current.children[word[i]-'A']
+		if current == nil {
+			return false
+		}
+	}
+	return current.isWordEnd
+}
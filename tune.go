@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Steps of the local search of TuneWeights: the weight moved between two
+// features starts at the first and is halved until it is below the second.
+const (
+	TUNE_STEP     = 0.05
+	TUNE_MIN_STEP = 0.001
+)
+
+// TuningSample is a position measured from one side's point of view, with
+// the result of the game for that side.
+type TuningSample struct {
+	// Measure of each of Features.
+	Features []float64
+	Result   float64
+}
+
+// NewTuningSamples measures a position reached in a game that ended with the
+// result for blue, once from each side's point of view.
+func NewTuningSamples(board *Board, trie *Trie, result float64) []TuningSample {
+	samples := make([]TuningSample, 0, 2)
+	for _, side := range []Mover{BlueMover, RedMover} {
+		sample := TuningSample{Features: make([]float64, len(Features)), Result: result}
+		if side == RedMover {
+			sample.Result = 1 - result
+		}
+		evaluation := NewEvaluation(board, side)
+		for idx, feature := range Features {
+			sample.Features[idx] = feature.Measurer(trie)(evaluation)
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// TuningError is the mean squared difference between the results of the
+// samples and what a LinearEvaluator with the weights, one for each of
+// Features, makes of their positions.
+func TuningError(samples []TuningSample, weights []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, sample := range samples {
+		score := 0.0
+		for idx, weight := range weights {
+			score += weight * sample.Features[idx]
+		}
+		total += (sample.Result - score) * (sample.Result - score)
+	}
+	return total / float64(len(samples))
+}
+
+// TuneWeights fits the weights of a LinearEvaluator to the results of the
+// samples, starting from the given weights, and returns the fitted weights
+// with their error.
+//
+// The fit is a least-squares local search on the linear score itself, with no
+// sigmoid in between since the score is already a probability of winning: it
+// moves a step of weight from one feature to another while that lowers the
+// error, and halves the step when no move does. Moving weight keeps the weights positive and
+// adding up to 1, so the evaluator still scores positions between 0 and 1.
+func TuneWeights(samples []TuningSample, initial map[string]float64) (map[string]float64, float64) {
+	weights := make([]float64, len(Features))
+	total := 0.0
+	for idx, feature := range Features {
+		weights[idx] = math.Max(initial[feature.Name], 0)
+		total += weights[idx]
+	}
+	for idx := range weights {
+		if total > 0 {
+			weights[idx] /= total
+		} else {
+			weights[idx] = 1 / float64(len(weights))
+		}
+	}
+
+	best := TuningError(samples, weights)
+	for step := TUNE_STEP; step >= TUNE_MIN_STEP; {
+		improved := false
+		for from := range weights {
+			for to := range weights {
+				if from == to || weights[from] < step {
+					continue
+				}
+				weights[from] -= step
+				weights[to] += step
+				if err := TuningError(samples, weights); err < best {
+					best = err
+					improved = true
+				} else {
+					weights[from] += step
+					weights[to] -= step
+				}
+			}
+		}
+		if !improved {
+			step /= 2
+		}
+	}
+
+	tuned := make(map[string]float64, len(Features))
+	for idx, feature := range Features {
+		tuned[feature.Name] = math.Round(weights[idx]*1e4) / 1e4
+	}
+	return tuned, best
+}
+
+// CollectTuningSamples plays a game from each board with the player on both
+// sides and measures every position of the games, labelled with their results.
+// It returns the samples and the results of the games for blue.
+func CollectTuningSamples(boards []*Board, trie *Trie, player *Player, maxMoves int, rng *rand.Rand) ([]TuningSample, []float64) {
+	samples := []TuningSample{}
+	results := make([]float64, 0, len(boards))
+	for _, board := range boards {
+		positions := []*Board{}
+		_, end := PlayGame(board, trie, player, player, maxMoves, rng, func(position *Board) {
+			positions = append(positions, position.clone())
+		})
+		result := GameResult(end)
+		for _, position := range positions {
+			samples = append(samples, NewTuningSamples(position, trie, result)...)
+		}
+		results = append(results, result)
+	}
+	return samples, results
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LineCountError is returned when the board does not have the 4n+1 lines of a hexagonal board.
+type LineCountError struct {
+	Got      int
+	Expected int
+}
+
+func (e *LineCountError) Error() string {
+	return fmt.Sprintf("board has %d lines, expected %d", e.Got, e.Expected)
+}
+
+// LineLengthError is returned when a line of the board has the wrong number of cells.
+type LineLengthError struct {
+	Line     int
+	Got      int
+	Expected int
+}
+
+func (e *LineLengthError) Error() string {
+	return fmt.Sprintf("line %d has %d cells, expected %d", e.Line, e.Got, e.Expected)
+}
+
+// MissingNodeError is returned when a cell of the board is null.
+type MissingNodeError struct {
+	Coords Coords
+}
+
+func (e *MissingNodeError) Error() string {
+	return fmt.Sprintf("node %d,%d is missing", e.Coords.Line, e.Coords.Col)
+}
+
+// InvalidCharError is returned when a cell does not hold a single letter from A to Z.
+type InvalidCharError struct {
+	Coords Coords
+	Char   string
+}
+
+func (e *InvalidCharError) Error() string {
+	return fmt.Sprintf("node %d,%d has char '%s'", e.Coords.Line, e.Coords.Col, e.Char)
+}
+
+// InvalidColorError is returned when a cell has a color other than the known Colors.
+type InvalidColorError struct {
+	Coords Coords
+	Color  Color
+}
+
+func (e *InvalidColorError) Error() string {
+	return fmt.Sprintf("node %d,%d has color '%s'", e.Coords.Line, e.Coords.Col, e.Color)
+}
+
+// ScoreError is returned when a side has an impossible score.
+type ScoreError struct {
+	Mover Mover
+	Score int
+}
+
+func (e *ScoreError) Error() string {
+	return fmt.Sprintf("%s score is %d, expected a score of at least 0", e.Mover, e.Score)
+}
+
+// MoverError is returned when the side to move is neither red nor blue.
+type MoverError struct {
+	Mover Mover
+}
+
+func (e *MoverError) Error() string {
+	return fmt.Sprintf("side to move is '%s', expected red or blue", e.Mover)
+}
+
+// BoardErrors holds every problem found by Board.Validate.
+type BoardErrors []error
+
+func (e BoardErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return "invalid board: " + strings.Join(messages, "; ")
+}
+
+// Validate checks a freshly decoded board before it is initialized. It returns
+// nil or BoardErrors listing every problem found.
+func (b *Board) Validate() error {
+	errs := BoardErrors{}
+
+	if b.Score.Red < 0 {
+		errs = append(errs, &ScoreError{Mover: RedMover, Score: b.Score.Red})
+	}
+	if b.Score.Blue < 0 {
+		errs = append(errs, &ScoreError{Mover: BlueMover, Score: b.Score.Blue})
+	}
+	switch b.ToMove {
+	case "", RedMover, BlueMover:
+	default:
+		errs = append(errs, &MoverError{Mover: b.ToMove})
+	}
+
+	radius := radiusForLines(len(b.Nodes))
+	if radius < 1 {
+		// Without a usable shape there's no way to tell which line is which.
+		errs = append(errs, &LineCountError{Got: len(b.Nodes), Expected: GetGeometry(BOARD_RADIUS).NumLines()})
+		return errs
+	}
+	geometry := GetGeometry(radius)
+
+	for lineNum, line := range b.Nodes {
+		if len(line) != geometry.LineLength(lineNum) {
+			errs = append(errs, &LineLengthError{Line: lineNum, Got: len(line), Expected: geometry.LineLength(lineNum)})
+		}
+		for nodeNum, node := range line {
+			coords := Coords{Line: lineNum, Col: nodeNum}
+			if node == nil {
+				errs = append(errs, &MissingNodeError{Coords: coords})
+				continue
+			}
+			if len(node.Char) != 1 || node.Char[0] < 'A' || node.Char[0] > 'Z' {
+				errs = append(errs, &InvalidCharError{Coords: coords, Char: node.Char})
+			}
+			switch node.Color {
+			case None, Red, Blue, VeryRed, VeryBlue:
+			default:
+				errs = append(errs, &InvalidColorError{Coords: coords, Color: node.Color})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Reasons a move can be illegal, in addition to the rule violations returned by Play and SwapNodes.
+var (
+	ErrEmptyMove      = errors.New("move has no tiles")
+	ErrOffBoard       = errors.New("tile is not on the board")
+	ErrNotAdjacent    = errors.New("tile is not adjacent to the previous tile")
+	ErrTileRepeated   = errors.New("tile is already part of the word")
+	ErrTileCleared    = errors.New("tile was cleared and has no letter yet")
+	ErrLetterMismatch = errors.New("letter does not match the board")
+	ErrInvalidSwap    = errors.New("a swap needs exactly two tiles")
+	ErrNotAWord       = errors.New("word is not in the dictionary")
+)
+
+// Values of MoveError.Step for problems that are not about a single step of the path.
+const (
+	SwapStep = -1
+	WordStep = -2
+)
+
+// MoveError describes the first problem ValidateMove found with a move.
+type MoveError struct {
+	// Index of the offending tile in the path, or SwapStep or WordStep.
+	Step   int
+	Coords Coords
+	Err    error
+}
+
+func (e *MoveError) Error() string {
+	switch e.Step {
+	case SwapStep:
+		return fmt.Sprintf("swap at %d,%d: %s", e.Coords.Line, e.Coords.Col, e.Err)
+	case WordStep:
+		return fmt.Sprintf("word: %s", e.Err)
+	}
+	return fmt.Sprintf("step %d at %d,%d: %s", e.Step, e.Coords.Line, e.Coords.Col, e.Err)
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateMove checks that mover can legally play the move on the board and
+// returns a MoveError for the first violation. The board is not modified.
+func ValidateMove(board *Board, move *Move, mover Mover, trie *Trie) error {
+	word := move.word
+	if len(word.letters) == 0 {
+		return &MoveError{Step: WordStep, Err: ErrEmptyMove}
+	}
+
+	if len(word.SwappedNodes) > 0 {
+		if len(word.SwappedNodes) != 2 {
+			return &MoveError{Step: SwapStep, Err: ErrInvalidSwap}
+		}
+		from, to := word.SwappedNodes[0], word.SwappedNodes[1]
+		for _, coords := range word.SwappedNodes {
+			if !board.geometry.IsValid(coords) {
+				return &MoveError{Step: SwapStep, Coords: coords, Err: ErrOffBoard}
+			}
+		}
+		if board.geometry.Distance(from, to) != 1 {
+			return &MoveError{Step: SwapStep, Coords: to, Err: ErrNotAdjacent}
+		}
+
+		hasSwapped := board.HasSwapped
+		board = board.clone()
+		board.HasSwapped = hasSwapped
+		if err := board.SwapNodes(from, to, false); err != nil {
+			var tileErr *TileError
+			if errors.As(err, &tileErr) {
+				return &MoveError{Step: SwapStep, Coords: tileErr.Coords, Err: tileErr.Err}
+			}
+			return &MoveError{Step: SwapStep, Coords: from, Err: err}
+		}
+	}
+
+	for idx, letter := range word.letters {
+		coords := letter.coords
+		if !board.geometry.IsValid(coords) {
+			return &MoveError{Step: idx, Coords: coords, Err: ErrOffBoard}
+		}
+		if idx > 0 && board.geometry.Distance(word.letters[idx-1].coords, coords) != 1 {
+			return &MoveError{Step: idx, Coords: coords, Err: ErrNotAdjacent}
+		}
+		for _, previous := range word.letters[:idx] {
+			if previous.coords == coords {
+				return &MoveError{Step: idx, Coords: coords, Err: ErrTileRepeated}
+			}
+		}
+
+		node := board.Nodes[coords.Line][coords.Col]
+		if !mover.IsMatching(node.Color) {
+			return &MoveError{Step: idx, Coords: coords, Err: ErrWrongColor}
+		}
+		if node.cleared {
+			return &MoveError{Step: idx, Coords: coords, Err: ErrTileCleared}
+		}
+		if node.Letter != letter.Letter {
+			return &MoveError{Step: idx, Coords: coords, Err: ErrLetterMismatch}
+		}
+	}
+
+	if !trie.Contains(word.String()) {
+		return &MoveError{Step: WordStep, Err: ErrNotAWord}
+	}
+
+	return nil
+}
@@ -17,7 +17,6 @@ type Word struct {
 	Probability  float64
 	NumGreyNodes int
 	SwappedNodes []Coords
-	board        *Board
 }
 
 type Move struct {
@@ -26,9 +25,6 @@ type Move struct {
 }
 
 func (m *Move) String(board *Board) string {
-	if board == nil {
-		board = m.word.board
-	}
 	if board == nil {
 		return m.word.String()
 	} else {
@@ -70,9 +66,27 @@ func (w *Word) Get(coords Coords) *WordLetter {
 	return nil
 }
 
+// Equal reports whether the words are the same move: the same swap and the same letters on the same cells.
+func (w *Word) Equal(other *Word) bool {
+	if len(w.letters) != len(other.letters) || len(w.SwappedNodes) != len(other.SwappedNodes) {
+		return false
+	}
+	for idx, letter := range w.letters {
+		if letter.coords != other.letters[idx].coords || letter.Letter != other.letters[idx].Letter {
+			return false
+		}
+	}
+	for idx, coords := range w.SwappedNodes {
+		if coords != other.SwappedNodes[idx] {
+			return false
+		}
+	}
+	return true
+}
+
 func FindWords(board *Board, trie *Trie, mover Mover) []*Word {
+	ws := newWordSearch(board, trie, mover)
 	result := []*Word{}
-	accumulation := []*AccumulatedNode{}
 	for lineNum := 0; lineNum < len(board.Nodes); lineNum++ {
 		for nodeNum := 0; nodeNum < len(board.Nodes[lineNum]); nodeNum++ {
 			node := board.Nodes[lineNum][nodeNum]
@@ -81,7 +95,7 @@ func FindWords(board *Board, trie *Trie, mover Mover) []*Word {
 				continue
 			}
 
-			result = append(result, findWordsRecursive(trie, board, mover, node, accumulation, 1.0, []Coords{})...)
+			result = append(result, ws.findWordsRecursive(node, 1.0, []Coords{})...)
 		}
 	}
 
@@ -110,100 +124,165 @@ func (a *AccumulatedNode) String() string {
 	return fmt.Sprintf("%c {%d %d}", a.Letter, a.coords.Line, a.coords.Col)
 }
 
-func findWordsRecursive(trie *Trie, board *Board, mover Mover, node *BoardNode, accumulation []*AccumulatedNode, probability float64, swappedNodes []Coords) []*Word {
+// tileState is what a word search may change about a tile while it tries
+// paths, swaps and refills.
+type tileState struct {
+	letter  byte
+	color   Color
+	cleared bool
+	used    bool
+}
+
+// wordSearch is the state of a FindWords call. The tiles are copied from the
+// board, so the board is only read and several searches can share it.
+type wordSearch struct {
+	board *Board
+	trie  *Trie
+	mover Mover
+	// Tiles by node index.
+	tiles        []tileState
+	accumulation []*AccumulatedNode
+	hasSwapped   bool
+}
+
+func newWordSearch(board *Board, trie *Trie, mover Mover) *wordSearch {
+	ws := &wordSearch{
+		board:        board,
+		trie:         trie,
+		mover:        mover,
+		tiles:        make([]tileState, board.geometry.NumCells()),
+		accumulation: []*AccumulatedNode{},
+		hasSwapped:   board.HasSwapped,
+	}
+	for _, node := range board.nodesFlat() {
+		ws.tiles[node.index] = tileState{letter: node.Letter, color: node.Color, cleared: node.cleared, used: node.used}
+	}
+	return ws
+}
+
+// Swaps the tiles of two nodes, like Board.SwapNodes.
+func (ws *wordSearch) swap(node1 *BoardNode, node2 *BoardNode) {
+	ws.tiles[node1.index], ws.tiles[node2.index] = ws.tiles[node2.index], ws.tiles[node1.index]
+	ws.hasSwapped = !ws.hasSwapped
+}
+
+func (ws *wordSearch) findWordsRecursive(node *BoardNode, probability float64, swappedNodes []Coords) []*Word {
 	result := []*Word{}
 	if probability < 0.01 {
 		return result
 	}
 
-	// Just skip any nodes that have been cleared, since 1/26 is really low and not worth it.
-	// TODO: Enable this for monte carlo simulations.
-	if node.cleared {
+	tile := &ws.tiles[node.index]
+	if tile.used {
 		return result
-		// originalLetter := node.Letter
-		// for _, letter := range lettersArray {
-		// 	node.Letter = letter
-		// 	node.cleared = false
-		// 	result = append(result, findWordsRecursive(trie, board, mover, node, accumulation, probability/26)...)
-		// }
-		// node.Letter = originalLetter
-		// node.cleared = true
-		// return result
-	}
-
-	if node.used {
+	}
+	if !ws.mover.IsMatching(tile.color) {
 		return result
 	}
-	if !mover.IsMatching(node.Color) {
+
+	// The letter that will refill a cleared tile isn't known yet, so this is a
+	// chance node: try every letter that continues a word, weighted by how likely
+	// it is to be dealt (see RefillDistribution). Play fills the tile with the
+	// letter of the word.
+	if tile.cleared {
+		nextLetters := ws.trie.NextLetters(ws.accumulation)
+		originalLetter := tile.letter
+		tile.cleared = false
+		for _, letter := range lettersArray {
+			if !nextLetters[letter] {
+				continue
+			}
+			tile.letter = letter
+			result = append(result, ws.findWordsRecursive(node, probability*RefillDistribution.Probability(letter), swappedNodes)...)
+		}
+		tile.letter = originalLetter
+		tile.cleared = true
 		return result
 	}
 
 	accumulatedNode := &AccumulatedNode{
-		Letter: node.Letter,
+		Letter: tile.letter,
 		coords: node.coords,
-		Color:  node.Color,
+		Color:  tile.color,
 	}
-	accumulation = append(accumulation, accumulatedNode)
-	node.used = true
+	ws.accumulation = append(ws.accumulation, accumulatedNode)
+	tile.used = true
 	defer func() {
-		node.used = false
-		accumulation = accumulation[:len(accumulation)-1]
+		tile.used = false
+		ws.accumulation = ws.accumulation[:len(ws.accumulation)-1]
 	}()
 
-	wordFindResult := trie.Find(accumulation)
-	if wordFindResult.IsWord && len(accumulation) >= MIN_WORD_LENGTH {
-		playedBoard := board.clone()
-		word := Word{letters: make([]*WordLetter, 0, len(accumulation)), Probability: probability, board: playedBoard, SwappedNodes: swappedNodes}
+	wordFindResult := ws.trie.Find(ws.accumulation)
+	if wordFindResult.IsWord && len(ws.accumulation) >= MIN_WORD_LENGTH {
+		word := Word{letters: make([]*WordLetter, 0, len(ws.accumulation)), Probability: probability, SwappedNodes: swappedNodes}
 		numGreyNodes := 0
-		for idx, node := range accumulation {
+		for idx, node := range ws.accumulation {
 			if node.Color == None {
 				numGreyNodes++
 			}
 			word.letters = append(word.letters, &WordLetter{coords: node.coords, Letter: node.Letter, IsStart: idx == 0})
 		}
 		word.NumGreyNodes = numGreyNodes
-		playedBoard.Play(&word, mover)
-
 		result = append(result, &word)
 	}
 	if !wordFindResult.IsPrefix {
 		return result
 	}
 
-	neighbors := board.GetNeighbors(node)
+	neighbors := ws.board.GetNeighbors(node)
 	for _, neighbor := range neighbors {
-		result = append(result, findWordsRecursive(trie, board, mover, neighbor, accumulation, probability, swappedNodes)...)
+		result = append(result, ws.findWordsRecursive(neighbor, probability, swappedNodes)...)
 
-		if neighbor.used || neighbor.Color == VeryBlue || neighbor.Color == VeryRed {
+		neighborTile := ws.tiles[neighbor.index]
+		if neighborTile.used || neighborTile.cleared || neighborTile.color == VeryBlue || neighborTile.color == VeryRed {
 			continue
 		}
 
-		coords := neighbor.coords
-
-		if !board.HasSwapped {
-			neighborNeighbors := board.GetNeighbors(neighbor)
+		if !ws.hasSwapped {
+			neighborNeighbors := ws.board.GetNeighbors(neighbor)
 			for _, neighborNeighbor := range neighborNeighbors {
-				// neighborNeighbor.used ensures that we won't continue with the current node
-				if neighborNeighbor.used || neighborNeighbor.cleared || neighborNeighbor.Color != None {
+				// neighborNeighbor.used ensures that we won't continue with the current node.
+				// Only swaps onto grey tiles are tried, whatever the rules allow.
+				other := ws.tiles[neighborNeighbor.index]
+				if other.used || other.cleared || other.color != None {
 					continue
 				}
 
-				for nextLetter := range wordFindResult.NextLetters {
-					if neighbor.Letter != nextLetter {
-						continue
-					}
-
-					swappedNodes = append(swappedNodes, coords, neighborNeighbor.coords)
-
-					board.SwapNodes(neighbor.coords, neighborNeighbor.coords, false)
-					result = append(result, findWordsRecursive(trie, board, mover, board.Nodes[coords.Line][coords.Col], accumulation, probability, swappedNodes)...)
-					board.SwapNodes(neighborNeighbor.coords, neighbor.coords, true)
+				if !wordFindResult.NextLetters[neighborTile.letter] {
+					continue
+				}
 
-					swappedNodes = swappedNodes[:0]
+				// No swap has been made, so the tiles on the board are the ones being swapped.
+				if err := ws.board.Rules().CanSwap(ws.board, neighbor, neighborNeighbor); err != nil {
+					continue
 				}
+				// Every word found through this swap keeps a reference to the slice, so it can't be reused.
+				swap := []Coords{neighbor.coords, neighborNeighbor.coords}
+
+				ws.swap(neighbor, neighborNeighbor)
+				result = append(result, ws.findWordsRecursive(neighbor, probability, swap)...)
+				ws.swap(neighborNeighbor, neighbor)
 			}
 		}
 	}
 
 	return result
 }
+
+// NewMove builds a move from the cells of a path, the word they spell and an
+// optional swap of two cells that is made before the path is played. Use
+// ValidateMove to check that it is legal on a board.
+func NewMove(mover Mover, path []Coords, swap []Coords, word string) (*Move, error) {
+	if len(word) != len(path) {
+		return nil, fmt.Errorf("word %s has %d letters but the path has %d cells", word, len(word), len(path))
+	}
+	word = strings.ToUpper(word)
+	letters := make([]*WordLetter, 0, len(path))
+	for idx, coords := range path {
+		letters = append(letters, &WordLetter{coords: coords, Letter: word[idx], IsStart: idx == 0})
+	}
+	return &Move{
+		word:  &Word{letters: letters, Probability: 1, SwappedNodes: swap},
+		Mover: mover,
+	}, nil
+}
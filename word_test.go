@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// FindWords doesn't modify the board, so searches can share it. Run with
+// -race to check for data races.
+func TestFindWordsConcurrent(t *testing.T) {
+	trie := loadTrie()
+	rng := rand.New(rand.NewSource(1))
+	board := RandomBoard(rng)
+	// Play until a hexagon is captured and its tiles are cleared, so the
+	// searches go through colored tiles, swaps and refills.
+	captured := false
+	for moveNum := 0; moveNum < 100 && !captured; moveNum++ {
+		mover := Mover(BlueMover)
+		if moveNum%2 == 1 {
+			mover = RedMover
+		}
+		words := FindWords(board, trie, mover)
+		certain := []*Word{}
+		for _, word := range words {
+			if word.Probability >= 1 {
+				certain = append(certain, word)
+			}
+		}
+		if len(certain) == 0 {
+			t.Fatal("no words found")
+		}
+		delta, err := board.MakeMove(certain[rng.Intn(len(certain))], mover)
+		if err != nil {
+			t.Fatal(err)
+		}
+		captured = len(delta.Hexagons) > 0
+	}
+	if !captured {
+		t.Fatal("no hexagon was captured")
+	}
+	hash := board.hash
+	expected := map[Mover][]*Word{}
+	for _, mover := range []Mover{BlueMover, RedMover} {
+		expected[mover] = FindWords(board, trie, mover)
+	}
+
+	const searches = 8
+	results := make([][]*Word, searches)
+	var wg sync.WaitGroup
+	for idx := 0; idx < searches; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			mover := Mover(BlueMover)
+			if idx%2 == 1 {
+				mover = RedMover
+			}
+			results[idx] = FindWords(board, trie, mover)
+		}(idx)
+	}
+	wg.Wait()
+
+	for idx, words := range results {
+		mover := Mover(BlueMover)
+		if idx%2 == 1 {
+			mover = RedMover
+		}
+		if len(words) != len(expected[mover]) {
+			t.Fatalf("search %d found %d words, expected %d", idx, len(words), len(expected[mover]))
+		}
+		for wordNum, word := range words {
+			if !word.Equal(expected[mover][wordNum]) {
+				t.Fatalf("search %d: word %d is %s, expected %s", idx, wordNum, word, expected[mover][wordNum])
+			}
+		}
+	}
+	if board.hash != hash {
+		t.Error("FindWords changed the board")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+// Zobrist hashing of board positions. Every feature of a position (a letter on
+// a cell, a color on a cell, ...) has a pseudo-random 64-bit key and the hash
+// of a position is the XOR of the keys of its features, so it can be updated
+// incrementally as the board changes.
+//
+// The keys are derived from the feature itself rather than from a random table,
+// so hashes are stable across runs and can be stored on disk.
+
+const (
+	zobristLetter uint64 = iota + 1
+	zobristColor
+	zobristCleared
+	zobristRedScore
+	zobristBlueScore
+	zobristRedToMove
+	zobristRedSide
+)
+
+// splitmix64 finalizer, see https://prng.di.unimi.it/splitmix64.c
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func zobristKey(feature uint64, index int, value uint64) uint64 {
+	return splitmix64(feature<<56 ^ uint64(index)<<32 ^ value)
+}
+
+func colorValue(color Color) uint64 {
+	switch color {
+	case Red:
+		return 1
+	case VeryRed:
+		return 2
+	case Blue:
+		return 3
+	case VeryBlue:
+		return 4
+	}
+	return 0
+}
+
+func nodeColorKey(node *BoardNode, color Color) uint64 {
+	if color == None {
+		return 0
+	}
+	return zobristKey(zobristColor, node.index, colorValue(color))
+}
+
+func nodeClearedKey(node *BoardNode, cleared bool) uint64 {
+	if !cleared {
+		return 0
+	}
+	return zobristKey(zobristCleared, node.index, 0)
+}
+
+func scoreKey(score BoardScore) uint64 {
+	return zobristKey(zobristRedScore, 0, uint64(score.Red)) ^ zobristKey(zobristBlueScore, 0, uint64(score.Blue))
+}
+
+// Hash of the position with the given side to move.
+func (b *Board) Hash(toMove Mover) uint64 {
+	if toMove == RedMover {
+		return b.hash ^ zobristKey(zobristRedToMove, 0, 0)
+	}
+	return b.hash
+}
+
+// computeHash hashes the board from scratch. Everything that changes the
+// board keeps b.hash up to date instead.
+func (b *Board) computeHash() uint64 {
+	hash := scoreKey(b.Score)
+	for _, node := range b.nodesFlat() {
+		hash ^= zobristKey(zobristLetter, node.index, uint64(node.Letter))
+		hash ^= nodeColorKey(node, node.Color)
+		hash ^= nodeClearedKey(node, node.cleared)
+	}
+	return hash
+}
+
+func (b *Board) setLetter(node *BoardNode, letter byte) {
+	b.hash ^= zobristKey(zobristLetter, node.index, uint64(node.Letter)) ^ zobristKey(zobristLetter, node.index, uint64(letter))
+	node.Letter = letter
+	node.Char = string(letter)
+	b.updateBits(node)
+}
+
+func (b *Board) setColor(node *BoardNode, color Color) {
+	b.hash ^= nodeColorKey(node, node.Color) ^ nodeColorKey(node, color)
+	node.Color = color
+	b.updateBits(node)
+}
+
+func (b *Board) setCleared(node *BoardNode, cleared bool) {
+	b.hash ^= nodeClearedKey(node, node.cleared) ^ nodeClearedKey(node, cleared)
+	node.cleared = cleared
+	b.updateBits(node)
+}
+
+func (b *Board) setScore(score BoardScore) {
+	b.hash ^= scoreKey(b.Score) ^ scoreKey(score)
+	b.Score = score
+	if b.bits != nil {
+		b.bits.Score = score
+	}
+}